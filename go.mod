@@ -4,7 +4,11 @@ go 1.24.0
 
 toolchain go1.24.11
 
-require github.com/pdfcpu/pdfcpu v0.11.1
+require (
+	github.com/pdfcpu/pdfcpu v0.11.1
+	golang.org/x/image v0.34.0
+	golang.org/x/text v0.32.0
+)
 
 require (
 	github.com/clipperhouse/uax29/v2 v2.2.0 // indirect
@@ -14,7 +18,5 @@ require (
 	github.com/mattn/go-runewidth v0.0.19 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	golang.org/x/crypto v0.43.0 // indirect
-	golang.org/x/image v0.34.0 // indirect
-	golang.org/x/text v0.32.0 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
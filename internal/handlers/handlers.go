@@ -8,11 +8,13 @@ import (
 	"html/template"
 	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"lovepdf/internal/image"
 	"lovepdf/internal/pdf"
@@ -26,6 +28,29 @@ type Response struct {
 	DownloadURL    string `json:"downloadUrl,omitempty"`
 	OriginalSize   int64  `json:"originalSize,omitempty"`
 	CompressedSize int64  `json:"compressedSize,omitempty"`
+
+	Steps     []pdf.CompressionStep `json:"steps,omitempty"`
+	PageCount int                   `json:"pageCount,omitempty"`
+	Metadata  *pdf.Metadata         `json:"metadata,omitempty"`
+	Info      *pdf.DocumentInfo     `json:"info,omitempty"`
+
+	// Restrictions removed along with a password
+	Restrictions []string `json:"restrictions,omitempty"`
+
+	// Text of each page extracted as JSON
+	Pages []pdf.PageText `json:"pages,omitempty"`
+
+	// Files embedded in a PDF
+	Attachments []pdf.Attachment `json:"attachments,omitempty"`
+
+	// Fields of a PDF form
+	Fields []pdf.FormField `json:"fields,omitempty"`
+	// Annotations of a PDF, page by page
+	Annotations []pdf.Annotation `json:"annotations,omitempty"`
+	// Outline of a PDF
+	Bookmarks []pdf.Bookmark `json:"bookmarks,omitempty"`
+	// Pages that alone exceed the size limit of a split
+	OversizedPages []int `json:"oversizedPages,omitempty"`
 }
 
 // Home renders the home page
@@ -72,6 +97,11 @@ func AddPasswordPage(w http.ResponseWriter, r *http.Request) {
 	renderTemplate(w, "add-password.html")
 }
 
+// ChangePasswordPage renders the change password page
+func ChangePasswordPage(w http.ResponseWriter, r *http.Request) {
+	renderTemplate(w, "change-password.html")
+}
+
 // RemovePagePage renders the remove page page
 func RemovePagePage(w http.ResponseWriter, r *http.Request) {
 	renderTemplate(w, "remove-page.html")
@@ -82,6 +112,76 @@ func ImageToPDFPage(w http.ResponseWriter, r *http.Request) {
 	renderTemplate(w, "image-to-pdf.html")
 }
 
+// RotatePage renders the rotate pages page
+func RotatePage(w http.ResponseWriter, r *http.Request) {
+	renderTemplate(w, "rotate.html")
+}
+
+// OrganizePage renders the organize pages page
+func OrganizePage(w http.ResponseWriter, r *http.Request) {
+	renderTemplate(w, "organize.html")
+}
+
+// WatermarkPage renders the watermark page
+func WatermarkPage(w http.ResponseWriter, r *http.Request) {
+	renderTemplate(w, "watermark.html")
+}
+
+// PageNumbersPage renders the page numbers page
+func PageNumbersPage(w http.ResponseWriter, r *http.Request) {
+	renderTemplate(w, "page-numbers.html")
+}
+
+// BatesPage renders the Bates numbering page
+func BatesPage(w http.ResponseWriter, r *http.Request) {
+	renderTemplate(w, "bates.html")
+}
+
+// InsertPage renders the insert pages page
+func InsertPage(w http.ResponseWriter, r *http.Request) {
+	renderTemplate(w, "insert.html")
+}
+
+// MetadataPage renders the metadata editor page
+func MetadataPage(w http.ResponseWriter, r *http.Request) {
+	renderTemplate(w, "metadata.html")
+}
+
+// ExtractImagesPage renders the extract images page
+func ExtractImagesPage(w http.ResponseWriter, r *http.Request) {
+	renderTemplate(w, "extract-images.html")
+}
+
+// ExtractTextPage renders the extract text page
+func ExtractTextPage(w http.ResponseWriter, r *http.Request) {
+	renderTemplate(w, "extract-text.html")
+}
+
+// AttachmentsPage renders the attachments page
+func AttachmentsPage(w http.ResponseWriter, r *http.Request) {
+	renderTemplate(w, "attachments.html")
+}
+
+// FormPage renders the form filling page
+func FormPage(w http.ResponseWriter, r *http.Request) {
+	renderTemplate(w, "form.html")
+}
+
+// FlattenPage renders the flatten page
+func FlattenPage(w http.ResponseWriter, r *http.Request) {
+	renderTemplate(w, "flatten.html")
+}
+
+// AnnotationsPage renders the annotations page
+func AnnotationsPage(w http.ResponseWriter, r *http.Request) {
+	renderTemplate(w, "annotations.html")
+}
+
+// BookmarksPage renders the bookmarks page
+func BookmarksPage(w http.ResponseWriter, r *http.Request) {
+	renderTemplate(w, "bookmarks.html")
+}
+
 // HandleSplit handles PDF splitting requests
 func HandleSplit(tmpDir string, maxMemory int64) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -120,17 +220,26 @@ func HandleSplit(tmpDir string, maxMemory int64) http.HandlerFunc {
 		defer os.Remove(inputPath)
 
 		// Get split options
-		splitMode := r.FormValue("splitMode")
-		pageRange := r.FormValue("pageRange")
+		splitMode := pdf.SplitMode(r.FormValue("splitMode"))
+		if splitMode == "" {
+			splitMode = pdf.SplitAll
+		}
 
-		// Split PDF
-		var outputPath string
-		if splitMode == "range" {
-			outputPath, err = pdf.SplitPDF(inputPath, tmpDir, pdf.SplitRange, pageRange)
-		} else {
-			outputPath, err = pdf.SplitPDF(inputPath, tmpDir, pdf.SplitAll, "")
+		opts := pdf.SplitOptions{
+			PageRange: r.FormValue("pageRange"),
+			Every:     parseIntWithDefault(r.FormValue("every"), 1, 1, 10000),
+			MaxBytes:  int64(parseIntWithDefault(r.FormValue("maxSizeMB"), 10, 1, 1000)) << 20,
+			Groups:    r.FormValue("pageGroups"),
+			Name:      header.Filename,
+		}
+
+		// Part names are given one per line; blank lines keep the default name
+		if partNames := strings.TrimRight(r.FormValue("partNames"), "\r\n"); partNames != "" {
+			opts.PartNames = strings.Split(strings.ReplaceAll(partNames, "\r\n", "\n"), "\n")
 		}
 
+		// Split PDF
+		result, err := pdf.SplitPDF(inputPath, tmpDir, splitMode, opts)
 		if err != nil {
 			log.Printf("Error splitting PDF: %v", err)
 			writeJSONError(w, fmt.Sprintf("Failed to split PDF: %v", err), http.StatusInternalServerError)
@@ -138,9 +247,27 @@ func HandleSplit(tmpDir string, maxMemory int64) http.HandlerFunc {
 		}
 
 		// Generate download URL
-		downloadURL := fmt.Sprintf("/download/%s", filepath.Base(outputPath))
+		downloadURL := fmt.Sprintf("/download/%s", filepath.Base(result.Path))
+
+		message := "PDF split successfully"
+		if n := len(result.OversizedPages); n > 0 {
+			pages := make([]string, n)
+			for i, page := range result.OversizedPages {
+				pages[i] = strconv.Itoa(page)
+			}
+			if n == 1 {
+				message += fmt.Sprintf(". Page %s is over the size limit on its own and was put in a separate file.", pages[0])
+			} else {
+				message += fmt.Sprintf(". Pages %s are over the size limit on their own and were each put in a separate file.", strings.Join(pages, ", "))
+			}
+		}
 
-		writeJSONSuccess(w, "PDF split successfully", downloadURL, 0, 0)
+		writeJSON(w, Response{
+			Success:        true,
+			Message:        message,
+			DownloadURL:    downloadURL,
+			OversizedPages: result.OversizedPages,
+		})
 	}
 }
 
@@ -170,32 +297,36 @@ func HandleMerge(tmpDir string, maxMemory int64) http.HandlerFunc {
 			return
 		}
 
-		// Save all uploaded files
-		var inputPaths []string
-		for i, fileHeader := range files {
-			if filepath.Ext(fileHeader.Filename) != ".pdf" {
-				writeJSONError(w, "Only PDF files are allowed", http.StatusBadRequest)
-				// Clean up previously saved files
-				for _, path := range inputPaths {
-					os.Remove(path)
-				}
-				return
-			}
+		opts := pdf.MergeOptions{
+			FileBookmarks:   r.FormValue("fileBookmarks") == "true",
+			TableOfContents: r.FormValue("tableOfContents") == "true",
+			Interleave:      r.FormValue("mergeMode") == "interleave",
+			ReverseSecond:   r.FormValue("reverseSecond") == "true",
+		}
 
-			file, err := fileHeader.Open()
-			if err != nil {
-				writeJSONError(w, "Failed to read uploaded file", http.StatusBadRequest)
-				return
-			}
-			defer file.Close()
+		if opts.Interleave && len(files) != 2 {
+			writeJSONError(w, "Interleaving needs exactly 2 PDF files", http.StatusBadRequest)
+			return
+		}
 
-			inputPath := filepath.Join(tmpDir, fmt.Sprintf("%s_input_%d.pdf", generateID(), i))
-			if err := saveUploadedFile(file, inputPath); err != nil {
-				log.Printf("Error saving file: %v", err)
-				writeJSONError(w, "Failed to save uploaded file", http.StatusInternalServerError)
-				return
-			}
-			inputPaths = append(inputPaths, inputPath)
+		// Optional page range per file, in the same order as the files
+		ranges := r.MultipartForm.Value["ranges[]"]
+		if len(ranges) > 0 && len(ranges) != len(files) {
+			writeJSONError(w, fmt.Sprintf("Got %d page ranges for %d files", len(ranges), len(files)), http.StatusBadRequest)
+			return
+		}
+
+		// Optional choice per file whether to keep its own bookmarks, kept by default
+		keepBookmarks := r.MultipartForm.Value["keepBookmarks[]"]
+		if len(keepBookmarks) > 0 && len(keepBookmarks) != len(files) {
+			writeJSONError(w, fmt.Sprintf("Got %d bookmark choices for %d files", len(keepBookmarks), len(files)), http.StatusBadRequest)
+			return
+		}
+
+		// Save all uploaded files
+		inputPaths, ok := saveUploadedPDFs(w, files, tmpDir)
+		if !ok {
+			return
 		}
 
 		// Clean up input files after processing
@@ -205,9 +336,21 @@ func HandleMerge(tmpDir string, maxMemory int64) http.HandlerFunc {
 			}
 		}()
 
+		// Pair each saved file with its original name for bookmarks and the table of contents
+		sources := make([]pdf.MergeSource, len(inputPaths))
+		for i, path := range inputPaths {
+			sources[i] = pdf.MergeSource{Path: path, Name: files[i].Filename}
+			if len(ranges) > 0 {
+				sources[i].Pages = ranges[i]
+			}
+			if len(keepBookmarks) > 0 {
+				sources[i].DropBookmarks = keepBookmarks[i] == "false"
+			}
+		}
+
 		// Merge PDFs
 		outputPath := filepath.Join(tmpDir, generateID()+"_merged.pdf")
-		if err := pdf.MergePDFs(inputPaths, outputPath); err != nil {
+		if err := pdf.MergePDFs(sources, outputPath, opts); err != nil {
 			log.Printf("Error merging PDFs: %v", err)
 			writeJSONError(w, fmt.Sprintf("Failed to merge PDFs: %v", err), http.StatusInternalServerError)
 			return
@@ -248,6 +391,17 @@ func HandleCompress(tmpDir string, maxMemory int64) http.HandlerFunc {
 			return
 		}
 
+		// Get compression level
+		compressionLevel := r.FormValue("compression")
+		if compressionLevel == "" {
+			compressionLevel = "medium"
+		}
+		level, err := pdf.ParseCompressionLevel(compressionLevel)
+		if err != nil {
+			writeJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		// Save uploaded file
 		inputPath := filepath.Join(tmpDir, generateID()+"_input.pdf")
 		if err := saveUploadedFile(file, inputPath); err != nil {
@@ -261,15 +415,10 @@ func HandleCompress(tmpDir string, maxMemory int64) http.HandlerFunc {
 		originalInfo, _ := os.Stat(inputPath)
 		originalSize := originalInfo.Size()
 
-		// Get compression level
-		compressionLevel := r.FormValue("compression")
-		if compressionLevel == "" {
-			compressionLevel = "medium"
-		}
-
 		// Compress PDF
 		outputPath := filepath.Join(tmpDir, generateID()+"_compressed.pdf")
-		if err := pdf.CompressPDF(inputPath, outputPath, pdf.CompressionLevel(compressionLevel)); err != nil {
+		steps, err := pdf.CompressPDF(inputPath, outputPath, level)
+		if err != nil {
 			log.Printf("Error compressing PDF: %v", err)
 			writeJSONError(w, fmt.Sprintf("Failed to compress PDF: %v", err), http.StatusInternalServerError)
 			return
@@ -282,7 +431,14 @@ func HandleCompress(tmpDir string, maxMemory int64) http.HandlerFunc {
 		// Generate download URL
 		downloadURL := fmt.Sprintf("/download/%s", filepath.Base(outputPath))
 
-		writeJSONSuccess(w, "PDF compressed successfully", downloadURL, originalSize, compressedSize)
+		writeJSON(w, Response{
+			Success:        true,
+			Message:        "PDF compressed successfully",
+			DownloadURL:    downloadURL,
+			OriginalSize:   originalSize,
+			CompressedSize: compressedSize,
+			Steps:          steps,
+		})
 	}
 }
 
@@ -482,6 +638,26 @@ func parseIntWithDefault(value string, defaultVal, min, max int) int {
 	return parsed
 }
 
+// parsePageOrder parses a comma separated list of page numbers like "3,1,2,2"
+func parsePageOrder(value string) ([]int, error) {
+	var order []int
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		page, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid page number: %s", part)
+		}
+		order = append(order, page)
+	}
+	if len(order) == 0 {
+		return nil, fmt.Errorf("Page order is required")
+	}
+	return order, nil
+}
+
 // applyGIFPreset applies compression presets
 func applyGIFPreset(preset string, opts image.GIFCompressionOptions) image.GIFCompressionOptions {
 	switch preset {
@@ -541,12 +717,8 @@ func HandleRemovePassword(tmpDir string, maxMemory int64) http.HandlerFunc {
 			return
 		}
 
-		// Get password
+		// Get password; PDFs that only restrict printing, copying and so on open without one
 		password := r.FormValue("password")
-		if password == "" {
-			writeJSONError(w, "Password is required", http.StatusBadRequest)
-			return
-		}
 
 		// Save uploaded file
 		inputPath := filepath.Join(tmpDir, generateID()+"_input.pdf")
@@ -559,16 +731,27 @@ func HandleRemovePassword(tmpDir string, maxMemory int64) http.HandlerFunc {
 
 		// Remove password from PDF
 		outputPath := filepath.Join(tmpDir, generateID()+"_unlocked.pdf")
-		if err := pdf.RemovePDFPassword(inputPath, outputPath, password); err != nil {
+		restrictions, err := pdf.RemovePDFPassword(inputPath, outputPath, password)
+		if err != nil {
 			log.Printf("Error removing password: %v", err)
-			writeJSONError(w, "Failed to remove password. Please check if the password is correct.", http.StatusBadRequest)
+			writeJSONError(w, fmt.Sprintf("Failed to remove password: %v", err), http.StatusBadRequest)
 			return
 		}
 
 		// Generate download URL
 		downloadURL := fmt.Sprintf("/download/%s", filepath.Base(outputPath))
 
-		writeJSONSuccess(w, "Password removed successfully. PDF is now unlocked and can be shared freely.", downloadURL, 0, 0)
+		message := "Password removed successfully. PDF is now unlocked and can be shared freely."
+		if len(restrictions) > 0 {
+			message += " Removed restrictions on " + strings.Join(restrictions, ", ") + "."
+		}
+
+		writeJSON(w, Response{
+			Success:      true,
+			Message:      message,
+			DownloadURL:  downloadURL,
+			Restrictions: restrictions,
+		})
 	}
 }
 
@@ -600,9 +783,15 @@ func HandleAddPassword(tmpDir string, maxMemory int64) http.HandlerFunc {
 			return
 		}
 
-		// Get password
-		password := r.FormValue("password")
-		if password == "" {
+		// Get passwords; "password" is the user password of the single-password form
+		opts, ok := parseEncryptOptions(w, r)
+		if !ok {
+			return
+		}
+		if opts.UserPassword == "" {
+			opts.UserPassword = r.FormValue("password")
+		}
+		if opts.UserPassword == "" && opts.OwnerPassword == "" {
 			writeJSONError(w, "Password is required", http.StatusBadRequest)
 			return
 		}
@@ -618,9 +807,9 @@ func HandleAddPassword(tmpDir string, maxMemory int64) http.HandlerFunc {
 
 		// Add password to PDF
 		outputPath := filepath.Join(tmpDir, generateID()+"_protected.pdf")
-		if err := pdf.AddPDFPassword(inputPath, outputPath, password); err != nil {
+		if err := pdf.AddPDFPassword(inputPath, outputPath, opts); err != nil {
 			log.Printf("Error adding password: %v", err)
-			writeJSONError(w, "Failed to add password to PDF.", http.StatusInternalServerError)
+			writeJSONError(w, fmt.Sprintf("Failed to add password to PDF: %v", err), http.StatusInternalServerError)
 			return
 		}
 
@@ -631,6 +820,95 @@ func HandleAddPassword(tmpDir string, maxMemory int64) http.HandlerFunc {
 	}
 }
 
+// HandleChangePassword handles requests to change the passwords or permissions of an encrypted PDF
+func HandleChangePassword(tmpDir string, maxMemory int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Parse multipart form
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			writeJSONError(w, "File too large or invalid form data", http.StatusBadRequest)
+			return
+		}
+
+		// Get uploaded file
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			writeJSONError(w, "No file uploaded", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		// Validate PDF
+		if filepath.Ext(header.Filename) != ".pdf" {
+			writeJSONError(w, "Only PDF files are allowed", http.StatusBadRequest)
+			return
+		}
+
+		// Get the current owner password and the new settings
+		currentPassword := r.FormValue("currentPassword")
+		if currentPassword == "" {
+			writeJSONError(w, "Current owner password is required", http.StatusBadRequest)
+			return
+		}
+
+		opts, ok := parseEncryptOptions(w, r)
+		if !ok {
+			return
+		}
+
+		// Save uploaded file; it is still encrypted
+		inputPath := filepath.Join(tmpDir, generateID()+"_input.pdf")
+		if err := saveUploadedFile(file, inputPath); err != nil {
+			log.Printf("Error saving file: %v", err)
+			writeJSONError(w, "Failed to save uploaded file", http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(inputPath)
+
+		// Re-encrypt in one step so no decrypted copy is written
+		outputPath := filepath.Join(tmpDir, generateID()+"_protected.pdf")
+		if err := pdf.ChangePassword(inputPath, outputPath, currentPassword, opts); err != nil {
+			log.Printf("Error changing password: %v", err)
+			writeJSONError(w, fmt.Sprintf("Failed to change password: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// Generate download URL
+		downloadURL := fmt.Sprintf("/download/%s", filepath.Base(outputPath))
+
+		writeJSONSuccess(w, "Password and permissions changed successfully.", downloadURL, 0, 0)
+	}
+}
+
+// parseEncryptOptions reads the passwords, permissions and algorithm of an encryption request.
+// Permissions are listed by name in "permissions" fields; without any, everything is allowed,
+// and a single empty field denies everything. On error it writes the response and returns false.
+func parseEncryptOptions(w http.ResponseWriter, r *http.Request) (pdf.EncryptOptions, bool) {
+	opts := pdf.EncryptOptions{
+		UserPassword:  r.FormValue("userPassword"),
+		OwnerPassword: r.FormValue("ownerPassword"),
+		Algorithm:     r.FormValue("algorithm"),
+		KeyLength:     parseIntWithDefault(r.FormValue("keyLength"), 0, 0, 256),
+		Permissions:   pdf.AllPermissions,
+		AllowWeak:     r.FormValue("allowWeak") == "true",
+	}
+
+	if names, ok := r.MultipartForm.Value["permissions"]; ok {
+		perms, err := pdf.ParsePermissions(names)
+		if err != nil {
+			writeJSONError(w, fmt.Sprintf("Invalid permissions: %v", err), http.StatusBadRequest)
+			return opts, false
+		}
+		opts.Permissions = perms
+	}
+
+	return opts, true
+}
+
 // HandleRemovePage handles PDF page removal requests
 func HandleRemovePage(tmpDir string, maxMemory int64) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -767,73 +1045,1620 @@ func HandleImageToPDF(tmpDir string, maxMemory int64) http.HandlerFunc {
 	}
 }
 
-// HandleDownload handles file download requests
-func HandleDownload(tmpDir string) http.HandlerFunc {
+// HandleRotate handles PDF page rotation requests
+func HandleRotate(tmpDir string, maxMemory int64) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		filename := filepath.Base(r.URL.Path)
-		filePath := filepath.Join(tmpDir, filename)
+		if r.Method != http.MethodPost {
+			writeJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
 
-		// Security check: ensure file is in tmp directory
-		absPath, err := filepath.Abs(filePath)
-		if err != nil {
-			http.Error(w, "Invalid file path", http.StatusBadRequest)
+		// Parse multipart form
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			writeJSONError(w, "File too large or invalid form data", http.StatusBadRequest)
 			return
 		}
 
-		absTmpDir, err := filepath.Abs(tmpDir)
+		// Get uploaded file
+		file, header, err := r.FormFile("file")
 		if err != nil {
-			http.Error(w, "Invalid tmp directory", http.StatusInternalServerError)
+			writeJSONError(w, "No file uploaded", http.StatusBadRequest)
 			return
 		}
+		defer file.Close()
 
-		if !filepath.HasPrefix(absPath, absTmpDir) {
-			http.Error(w, "Access denied", http.StatusForbidden)
+		// Validate PDF
+		if filepath.Ext(header.Filename) != ".pdf" {
+			writeJSONError(w, "Only PDF files are allowed", http.StatusBadRequest)
 			return
 		}
 
-		// Check if file exists
-		if _, err := os.Stat(filePath); os.IsNotExist(err) {
-			http.Error(w, "File not found", http.StatusNotFound)
-			return
+		// Get rotation options (an empty page range rotates every page)
+		pageRange := r.FormValue("pageRange")
+		degrees := 90
+		if d := r.FormValue("degrees"); d != "" {
+			parsed, err := strconv.Atoi(d)
+			if err != nil {
+				writeJSONError(w, "Invalid rotation angle", http.StatusBadRequest)
+				return
+			}
+			degrees = parsed
 		}
 
-		// Serve file
-		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
-		http.ServeFile(w, r, filePath)
-	}
-}
+		// Save uploaded file
+		inputPath := filepath.Join(tmpDir, generateID()+"_input.pdf")
+		if err := saveUploadedFile(file, inputPath); err != nil {
+			log.Printf("Error saving file: %v", err)
+			writeJSONError(w, "Failed to save uploaded file", http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(inputPath)
 
-// Helper functions
+		// Rotate pages
+		outputPath := filepath.Join(tmpDir, generateID()+"_rotated.pdf")
+		if err := pdf.RotatePages(inputPath, outputPath, pageRange, degrees); err != nil {
+			log.Printf("Error rotating pages: %v", err)
+			writeJSONError(w, fmt.Sprintf("Failed to rotate pages: %v", err), http.StatusInternalServerError)
+			return
+		}
 
-func renderTemplate(w http.ResponseWriter, tmpl string) {
-	t, err := template.ParseFiles(filepath.Join("web", "templates", tmpl))
-	if err != nil {
-		log.Printf("Error parsing template: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
+		// Generate download URL
+		downloadURL := fmt.Sprintf("/download/%s", filepath.Base(outputPath))
 
-	if err := t.Execute(w, nil); err != nil {
-		log.Printf("Error executing template: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeJSONSuccess(w, "Pages rotated successfully.", downloadURL, 0, 0)
 	}
 }
 
-func writeJSONSuccess(w http.ResponseWriter, message, downloadURL string, originalSize, compressedSize int64) {
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(Response{
-		Success:        true,
-		Message:        message,
-		DownloadURL:    downloadURL,
-		OriginalSize:   originalSize,
-		CompressedSize: compressedSize,
-	})
-}
+// HandleOrganize handles PDF page reordering requests
+func HandleOrganize(tmpDir string, maxMemory int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
 
-func writeJSONError(w http.ResponseWriter, message string, statusCode int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(Response{
+		// Parse multipart form
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			writeJSONError(w, "File too large or invalid form data", http.StatusBadRequest)
+			return
+		}
+
+		// Get uploaded file
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			writeJSONError(w, "No file uploaded", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		// Validate PDF
+		if filepath.Ext(header.Filename) != ".pdf" {
+			writeJSONError(w, "Only PDF files are allowed", http.StatusBadRequest)
+			return
+		}
+
+		// Get new page order, e.g. "3,1,2,2"
+		order, err := parsePageOrder(r.FormValue("order"))
+		if err != nil {
+			writeJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Save uploaded file
+		inputPath := filepath.Join(tmpDir, generateID()+"_input.pdf")
+		if err := saveUploadedFile(file, inputPath); err != nil {
+			log.Printf("Error saving file: %v", err)
+			writeJSONError(w, "Failed to save uploaded file", http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(inputPath)
+
+		// Reorder pages
+		outputPath := filepath.Join(tmpDir, generateID()+"_organized.pdf")
+		if err := pdf.ReorderPages(inputPath, outputPath, order); err != nil {
+			log.Printf("Error reordering pages: %v", err)
+			writeJSONError(w, fmt.Sprintf("Failed to reorder pages: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// Generate download URL
+		downloadURL := fmt.Sprintf("/download/%s", filepath.Base(outputPath))
+
+		writeJSONSuccess(w, "Pages reorganized successfully.", downloadURL, 0, 0)
+	}
+}
+
+// HandlePageCount returns the number of pages in an uploaded PDF
+func HandlePageCount(tmpDir string, maxMemory int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Parse multipart form
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			writeJSONError(w, "File too large or invalid form data", http.StatusBadRequest)
+			return
+		}
+
+		// Get uploaded file
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			writeJSONError(w, "No file uploaded", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		// Validate PDF
+		if filepath.Ext(header.Filename) != ".pdf" {
+			writeJSONError(w, "Only PDF files are allowed", http.StatusBadRequest)
+			return
+		}
+
+		// Save uploaded file
+		inputPath := filepath.Join(tmpDir, generateID()+"_input.pdf")
+		if err := saveUploadedFile(file, inputPath); err != nil {
+			log.Printf("Error saving file: %v", err)
+			writeJSONError(w, "Failed to save uploaded file", http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(inputPath)
+
+		count, err := pdf.PageCount(inputPath)
+		if err != nil {
+			log.Printf("Error reading page count: %v", err)
+			writeJSONError(w, "Failed to read PDF", http.StatusBadRequest)
+			return
+		}
+
+		writeJSON(w, Response{Success: true, PageCount: count})
+	}
+}
+
+// HandleWatermark handles PDF watermarking requests
+func HandleWatermark(tmpDir string, maxMemory int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Parse multipart form
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			writeJSONError(w, "File too large or invalid form data", http.StatusBadRequest)
+			return
+		}
+
+		// Get uploaded file
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			writeJSONError(w, "No file uploaded", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		// Validate PDF
+		if filepath.Ext(header.Filename) != ".pdf" {
+			writeJSONError(w, "Only PDF files are allowed", http.StatusBadRequest)
+			return
+		}
+
+		// Parse watermark options
+		opts := pdf.WatermarkOptions{
+			Text:     r.FormValue("text"),
+			FontName: r.FormValue("fontName"),
+			FontSize: parseIntWithDefault(r.FormValue("fontSize"), 48, 1, 500),
+			Color:    r.FormValue("color"),
+			Opacity:  float64(parseIntWithDefault(r.FormValue("opacity"), 50, 0, 100)) / 100,
+			Rotation: float64(parseIntWithDefault(r.FormValue("rotation"), 45, -180, 180)),
+			Position: r.FormValue("position"),
+			Scale:    float64(parseIntWithDefault(r.FormValue("scale"), 50, 1, 100)) / 100,
+			Mode:     pdf.WatermarkMode(r.FormValue("mode")),
+			Pages:    r.FormValue("pageRange"),
+		}
+		if opts.FontName == "" {
+			opts.FontName = "Helvetica"
+		}
+		if opts.Color == "" {
+			opts.Color = "#808080"
+		}
+		if opts.Position == "" {
+			opts.Position = "c"
+		}
+		if opts.Mode == "" {
+			opts.Mode = pdf.WatermarkBehind
+		}
+
+		// Save uploaded file
+		inputPath := filepath.Join(tmpDir, generateID()+"_input.pdf")
+		if err := saveUploadedFile(file, inputPath); err != nil {
+			log.Printf("Error saving file: %v", err)
+			writeJSONError(w, "Failed to save uploaded file", http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(inputPath)
+
+		// Save watermark image for image watermarks
+		if r.FormValue("watermarkType") == "image" {
+			imageFile, imageHeader, err := r.FormFile("image")
+			if err != nil {
+				writeJSONError(w, "No watermark image uploaded", http.StatusBadRequest)
+				return
+			}
+			defer imageFile.Close()
+
+			ext := strings.ToLower(filepath.Ext(imageHeader.Filename))
+			if ext != ".jpg" && ext != ".jpeg" && ext != ".png" {
+				writeJSONError(w, "Only JPEG and PNG watermark images are allowed", http.StatusBadRequest)
+				return
+			}
+
+			imagePath := filepath.Join(tmpDir, generateID()+"_watermark"+ext)
+			if err := saveUploadedFile(imageFile, imagePath); err != nil {
+				log.Printf("Error saving file: %v", err)
+				writeJSONError(w, "Failed to save uploaded file", http.StatusInternalServerError)
+				return
+			}
+			defer os.Remove(imagePath)
+
+			opts.ImagePath = imagePath
+		} else if strings.TrimSpace(opts.Text) == "" {
+			writeJSONError(w, "Watermark text is required", http.StatusBadRequest)
+			return
+		}
+
+		// Apply watermark
+		outputPath := filepath.Join(tmpDir, generateID()+"_watermarked.pdf")
+		if err := pdf.Watermark(inputPath, outputPath, opts); err != nil {
+			log.Printf("Error adding watermark: %v", err)
+			writeJSONError(w, fmt.Sprintf("Failed to add watermark: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// Generate download URL
+		downloadURL := fmt.Sprintf("/download/%s", filepath.Base(outputPath))
+
+		writeJSONSuccess(w, "Watermark added successfully.", downloadURL, 0, 0)
+	}
+}
+
+// HandlePageNumbers handles page numbering and header/footer requests
+func HandlePageNumbers(tmpDir string, maxMemory int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Parse multipart form
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			writeJSONError(w, "File too large or invalid form data", http.StatusBadRequest)
+			return
+		}
+
+		// Get uploaded file
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			writeJSONError(w, "No file uploaded", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		// Validate PDF
+		if filepath.Ext(header.Filename) != ".pdf" {
+			writeJSONError(w, "Only PDF files are allowed", http.StatusBadRequest)
+			return
+		}
+
+		// Parse numbering options
+		opts := pdf.PageNumberOptions{
+			Format:   r.FormValue("format"),
+			Position: r.FormValue("position"),
+			Start:    parseIntWithDefault(r.FormValue("start"), 1, 0, 1000000),
+			Pages:    r.FormValue("pageRange"),
+			Skip:     r.FormValue("skip"),
+			FontName: r.FormValue("fontName"),
+			FontSize: parseIntWithDefault(r.FormValue("fontSize"), 10, 1, 200),
+			Color:    r.FormValue("color"),
+			Header:   r.FormValue("header"),
+			Footer:   r.FormValue("footer"),
+			Filename: header.Filename,
+		}
+		if opts.Position == "" {
+			opts.Position = "bc"
+		}
+		if opts.FontName == "" {
+			opts.FontName = "Helvetica"
+		}
+		if opts.Color == "" {
+			opts.Color = "#000000"
+		}
+
+		// Save uploaded file
+		inputPath := filepath.Join(tmpDir, generateID()+"_input.pdf")
+		if err := saveUploadedFile(file, inputPath); err != nil {
+			log.Printf("Error saving file: %v", err)
+			writeJSONError(w, "Failed to save uploaded file", http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(inputPath)
+
+		// Stamp page numbers
+		outputPath := filepath.Join(tmpDir, generateID()+"_numbered.pdf")
+		if err := pdf.AddPageNumbers(inputPath, outputPath, opts); err != nil {
+			log.Printf("Error adding page numbers: %v", err)
+			writeJSONError(w, fmt.Sprintf("Failed to add page numbers: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// Generate download URL
+		downloadURL := fmt.Sprintf("/download/%s", filepath.Base(outputPath))
+
+		writeJSONSuccess(w, "Page numbers added successfully.", downloadURL, 0, 0)
+	}
+}
+
+// HandleBates handles Bates numbering requests across one or more PDFs
+func HandleBates(tmpDir string, maxMemory int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Parse multipart form
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			writeJSONError(w, "Files too large or invalid form data", http.StatusBadRequest)
+			return
+		}
+
+		// Get uploaded files
+		files := r.MultipartForm.File["files"]
+		if len(files) == 0 {
+			writeJSONError(w, "No files uploaded", http.StatusBadRequest)
+			return
+		}
+
+		if len(files) > 20 {
+			writeJSONError(w, "Maximum 20 files allowed", http.StatusBadRequest)
+			return
+		}
+
+		// Parse Bates options
+		opts := pdf.BatesOptions{
+			Prefix:   r.FormValue("prefix"),
+			Suffix:   r.FormValue("suffix"),
+			Digits:   parseIntWithDefault(r.FormValue("digits"), 6, 0, 12),
+			Start:    parseIntWithDefault(r.FormValue("start"), 1, 0, 1000000000),
+			Position: r.FormValue("position"),
+			FontName: r.FormValue("fontName"),
+			FontSize: parseIntWithDefault(r.FormValue("fontSize"), 10, 1, 200),
+			Color:    r.FormValue("color"),
+		}
+		if opts.Position == "" {
+			opts.Position = "br"
+		}
+		if opts.FontName == "" {
+			opts.FontName = "Helvetica"
+		}
+		if opts.Color == "" {
+			opts.Color = "#000000"
+		}
+
+		// Save all uploaded files
+		inputPaths, ok := saveUploadedPDFs(w, files, tmpDir)
+		if !ok {
+			return
+		}
+
+		// Clean up input files after processing
+		defer func() {
+			for _, path := range inputPaths {
+				os.Remove(path)
+			}
+		}()
+
+		sources := make([]pdf.BatesSource, len(inputPaths))
+		for i, path := range inputPaths {
+			sources[i] = pdf.BatesSource{Path: path, Name: files[i].Filename}
+		}
+
+		// Stamp Bates numbers
+		outputPath, err := pdf.BatesStamp(sources, tmpDir, opts)
+		if err != nil {
+			log.Printf("Error adding Bates numbers: %v", err)
+			writeJSONError(w, fmt.Sprintf("Failed to add Bates numbers: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// Generate download URL
+		downloadURL := fmt.Sprintf("/download/%s", filepath.Base(outputPath))
+
+		writeJSONSuccess(w, "Bates numbers added successfully.", downloadURL, 0, 0)
+	}
+}
+
+// HandleInsert handles requests to insert blank pages or another PDF
+func HandleInsert(tmpDir string, maxMemory int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Parse multipart form
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			writeJSONError(w, "File too large or invalid form data", http.StatusBadRequest)
+			return
+		}
+
+		// Get uploaded file
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			writeJSONError(w, "No file uploaded", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		// Validate PDF
+		if filepath.Ext(header.Filename) != ".pdf" {
+			writeJSONError(w, "Only PDF files are allowed", http.StatusBadRequest)
+			return
+		}
+
+		// Parse insert options
+		opts := pdf.InsertOptions{
+			Pages:     r.FormValue("pageRange"),
+			Before:    r.FormValue("position") == "before",
+			Count:     parseIntWithDefault(r.FormValue("count"), 1, 1, 100),
+			PaperSize: r.FormValue("paperSize"),
+		}
+		if r.FormValue("target") == "every" {
+			opts.Every = parseIntWithDefault(r.FormValue("every"), 1, 1, 10000)
+		} else if strings.TrimSpace(opts.Pages) == "" {
+			writeJSONError(w, "Please enter the pages to insert next to", http.StatusBadRequest)
+			return
+		}
+
+		// Save uploaded file
+		inputPath := filepath.Join(tmpDir, generateID()+"_input.pdf")
+		if err := saveUploadedFile(file, inputPath); err != nil {
+			log.Printf("Error saving file: %v", err)
+			writeJSONError(w, "Failed to save uploaded file", http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(inputPath)
+
+		// Save the PDF to insert when inserting a document
+		if r.FormValue("insertType") == "pdf" {
+			insertFile, insertHeader, err := r.FormFile("insertFile")
+			if err != nil {
+				writeJSONError(w, "No PDF to insert uploaded", http.StatusBadRequest)
+				return
+			}
+			defer insertFile.Close()
+
+			if filepath.Ext(insertHeader.Filename) != ".pdf" {
+				writeJSONError(w, "Only PDF files are allowed", http.StatusBadRequest)
+				return
+			}
+
+			insertPath := filepath.Join(tmpDir, generateID()+"_insert.pdf")
+			if err := saveUploadedFile(insertFile, insertPath); err != nil {
+				log.Printf("Error saving file: %v", err)
+				writeJSONError(w, "Failed to save uploaded file", http.StatusInternalServerError)
+				return
+			}
+			defer os.Remove(insertPath)
+
+			opts.InsertPath = insertPath
+		}
+
+		// Insert pages
+		outputPath := filepath.Join(tmpDir, generateID()+"_inserted.pdf")
+		if err := pdf.InsertPages(inputPath, outputPath, opts); err != nil {
+			log.Printf("Error inserting pages: %v", err)
+			writeJSONError(w, fmt.Sprintf("Failed to insert pages: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// Generate download URL
+		downloadURL := fmt.Sprintf("/download/%s", filepath.Base(outputPath))
+
+		writeJSONSuccess(w, "Pages inserted successfully.", downloadURL, 0, 0)
+	}
+}
+
+// HandleMetadata inspects, edits or strips the metadata of a PDF.
+// The action form value selects "inspect" (the default), "write" or "strip".
+func HandleMetadata(tmpDir string, maxMemory int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Parse multipart form
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			writeJSONError(w, "File too large or invalid form data", http.StatusBadRequest)
+			return
+		}
+
+		action := r.FormValue("action")
+		if action == "" {
+			action = "inspect"
+		}
+		if action != "inspect" && action != "write" && action != "strip" {
+			writeJSONError(w, "Invalid action", http.StatusBadRequest)
+			return
+		}
+
+		// Get uploaded file
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			writeJSONError(w, "No file uploaded", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		// Validate PDF
+		if filepath.Ext(header.Filename) != ".pdf" {
+			writeJSONError(w, "Only PDF files are allowed", http.StatusBadRequest)
+			return
+		}
+
+		// Save uploaded file
+		inputPath := filepath.Join(tmpDir, generateID()+"_input.pdf")
+		if err := saveUploadedFile(file, inputPath); err != nil {
+			log.Printf("Error saving file: %v", err)
+			writeJSONError(w, "Failed to save uploaded file", http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(inputPath)
+
+		if action == "strip" {
+			outputPath := filepath.Join(tmpDir, generateID()+"_stripped.pdf")
+			if err := pdf.StripMetadata(inputPath, outputPath); err != nil {
+				log.Printf("Error stripping metadata: %v", err)
+				writeJSONError(w, fmt.Sprintf("Failed to strip metadata: %v", err), http.StatusInternalServerError)
+				return
+			}
+
+			downloadURL := fmt.Sprintf("/download/%s", filepath.Base(outputPath))
+			writeJSONSuccess(w, "All metadata removed successfully.", downloadURL, 0, 0)
+			return
+		}
+
+		md, err := pdf.ReadMetadata(inputPath)
+		if err != nil {
+			log.Printf("Error reading metadata: %v", err)
+			writeJSONError(w, "Failed to read PDF", http.StatusBadRequest)
+			return
+		}
+
+		if action == "inspect" {
+			writeJSON(w, Response{Success: true, Metadata: &md})
+			return
+		}
+
+		if err := applyMetadataForm(&md, r.MultipartForm.Value); err != nil {
+			writeJSONError(w, fmt.Sprintf("Invalid %v", err), http.StatusBadRequest)
+			return
+		}
+
+		outputPath := filepath.Join(tmpDir, generateID()+"_metadata.pdf")
+		if err := pdf.WriteMetadata(inputPath, outputPath, md); err != nil {
+			log.Printf("Error writing metadata: %v", err)
+			writeJSONError(w, fmt.Sprintf("Failed to write metadata: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// Generate download URL
+		downloadURL := fmt.Sprintf("/download/%s", filepath.Base(outputPath))
+
+		writeJSONSuccess(w, "Metadata updated successfully.", downloadURL, 0, 0)
+	}
+}
+
+// applyMetadataForm changes the standard fields of md that the form sends.
+// A field that is sent empty is cleared; fields that are not sent, and custom
+// entries, keep their value.
+func applyMetadataForm(md *pdf.Metadata, values map[string][]string) error {
+	text := []struct {
+		key   string
+		field *string
+	}{
+		{"title", &md.Title},
+		{"author", &md.Author},
+		{"subject", &md.Subject},
+		{"keywords", &md.Keywords},
+		{"creator", &md.Creator},
+		{"producer", &md.Producer},
+	}
+	for _, t := range text {
+		if v, ok := values[t.key]; ok && len(v) > 0 {
+			*t.field = strings.TrimSpace(v[0])
+		}
+	}
+
+	dates := []struct {
+		key   string
+		name  string
+		field **time.Time
+	}{
+		{"creationDate", "creation date", &md.CreationDate},
+		{"modDate", "modification date", &md.ModDate},
+	}
+	for _, d := range dates {
+		v, ok := values[d.key]
+		if !ok || len(v) == 0 {
+			continue
+		}
+		t, err := parseMetadataDate(v[0])
+		if err != nil {
+			return fmt.Errorf("%s: %w", d.name, err)
+		}
+		*d.field = t
+	}
+
+	return nil
+}
+
+// parseMetadataDate parses an RFC 3339 date, or a date and time without a zone in UTC.
+// An empty value returns nil, which leaves the date out.
+func parseMetadataDate(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("expected a date like 2024-01-31T09:30:00Z, got %q", value)
+}
+
+// HandleInfo returns the page boxes, encryption, fonts and other facts of an uploaded PDF
+func HandleInfo(tmpDir string, maxMemory int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Parse multipart form
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			writeJSONError(w, "File too large or invalid form data", http.StatusBadRequest)
+			return
+		}
+
+		// Get uploaded file
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			writeJSONError(w, "No file uploaded", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		// Validate PDF
+		if filepath.Ext(header.Filename) != ".pdf" {
+			writeJSONError(w, "Only PDF files are allowed", http.StatusBadRequest)
+			return
+		}
+
+		// Save uploaded file
+		inputPath := filepath.Join(tmpDir, generateID()+"_input.pdf")
+		if err := saveUploadedFile(file, inputPath); err != nil {
+			log.Printf("Error saving file: %v", err)
+			writeJSONError(w, "Failed to save uploaded file", http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(inputPath)
+
+		// The password is only needed for documents that cannot be opened without one
+		info, err := pdf.Inspect(inputPath, r.FormValue("password"))
+		if err != nil {
+			log.Printf("Error inspecting PDF: %v", err)
+			writeJSONError(w, fmt.Sprintf("Failed to inspect PDF: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		writeJSON(w, Response{Success: true, Info: &info})
+	}
+}
+
+// HandleExtractImages handles requests to extract the embedded images of a PDF as a ZIP
+func HandleExtractImages(tmpDir string, maxMemory int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Parse multipart form
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			writeJSONError(w, "File too large or invalid form data", http.StatusBadRequest)
+			return
+		}
+
+		// Get uploaded file
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			writeJSONError(w, "No file uploaded", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		// Validate PDF
+		if filepath.Ext(header.Filename) != ".pdf" {
+			writeJSONError(w, "Only PDF files are allowed", http.StatusBadRequest)
+			return
+		}
+
+		// Save uploaded file
+		inputPath := filepath.Join(tmpDir, generateID()+"_input.pdf")
+		if err := saveUploadedFile(file, inputPath); err != nil {
+			log.Printf("Error saving file: %v", err)
+			writeJSONError(w, "Failed to save uploaded file", http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(inputPath)
+
+		opts := pdf.ExtractImagesOptions{
+			Pages:     r.FormValue("pageRange"),
+			MinWidth:  parseIntWithDefault(r.FormValue("minWidth"), 0, 0, 100000),
+			MinHeight: parseIntWithDefault(r.FormValue("minHeight"), 0, 0, 100000),
+		}
+		if r.FormValue("compress") == "true" {
+			opts.Quality = parseIntWithDefault(r.FormValue("quality"), 75, 1, 100)
+		}
+
+		// Extract images
+		outputPath, count, err := pdf.ExtractImages(inputPath, tmpDir, opts)
+		if err != nil {
+			log.Printf("Error extracting images: %v", err)
+			writeJSONError(w, fmt.Sprintf("Failed to extract images: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// Generate download URL
+		downloadURL := fmt.Sprintf("/download/%s", filepath.Base(outputPath))
+
+		message := fmt.Sprintf("Extracted %d images.", count)
+		if count == 1 {
+			message = "Extracted 1 image."
+		}
+
+		writeJSONSuccess(w, message, downloadURL, 0, 0)
+	}
+}
+
+// HandleExtractText handles requests to extract the text of a PDF.
+// The format form value selects a "txt" download (the default) or "json", which
+// is also returned in the response.
+func HandleExtractText(tmpDir string, maxMemory int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Parse multipart form
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			writeJSONError(w, "File too large or invalid form data", http.StatusBadRequest)
+			return
+		}
+
+		// Get uploaded file
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			writeJSONError(w, "No file uploaded", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		// Validate PDF
+		if filepath.Ext(header.Filename) != ".pdf" {
+			writeJSONError(w, "Only PDF files are allowed", http.StatusBadRequest)
+			return
+		}
+
+		format := r.FormValue("format")
+		if format == "" {
+			format = "txt"
+		}
+		if format != "txt" && format != "json" {
+			writeJSONError(w, fmt.Sprintf("Unknown format: %s", format), http.StatusBadRequest)
+			return
+		}
+
+		// Save uploaded file
+		inputPath := filepath.Join(tmpDir, generateID()+"_input.pdf")
+		if err := saveUploadedFile(file, inputPath); err != nil {
+			log.Printf("Error saving file: %v", err)
+			writeJSONError(w, "Failed to save uploaded file", http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(inputPath)
+
+		opts := pdf.TextOptions{
+			Pages: r.FormValue("pageRange"),
+			Runs:  format == "json" && r.FormValue("runs") == "true",
+		}
+
+		// Extract text
+		pages, err := pdf.ExtractText(inputPath, opts)
+		if err != nil {
+			log.Printf("Error extracting text: %v", err)
+			writeJSONError(w, fmt.Sprintf("Failed to extract text: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		outputPath := filepath.Join(tmpDir, generateID()+"_text."+format)
+		if format == "json" {
+			err = pdf.WriteTextJSON(outputPath, pages)
+		} else {
+			err = pdf.WritePlainText(outputPath, pages)
+		}
+		if err != nil {
+			log.Printf("Error writing text: %v", err)
+			writeJSONError(w, fmt.Sprintf("Failed to extract text: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// Generate download URL
+		downloadURL := fmt.Sprintf("/download/%s", filepath.Base(outputPath))
+
+		resp := Response{
+			Success:     true,
+			Message:     "Text extracted successfully.",
+			DownloadURL: downloadURL,
+		}
+		if format == "json" {
+			resp.Pages = pages
+		}
+		writeJSON(w, resp)
+	}
+}
+
+// HandleAttachments lists, adds, extracts or removes the files embedded in a PDF.
+// The action form value selects "list" (the default), "add", "extract" or "remove".
+// Extract and remove work on the attachments named in the names form values;
+// extract takes all of them when none are named, remove only with all=true.
+func HandleAttachments(tmpDir string, maxMemory int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Parse multipart form
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			writeJSONError(w, "File too large or invalid form data", http.StatusBadRequest)
+			return
+		}
+
+		action := r.FormValue("action")
+		if action == "" {
+			action = "list"
+		}
+		if action != "list" && action != "add" && action != "extract" && action != "remove" {
+			writeJSONError(w, "Invalid action", http.StatusBadRequest)
+			return
+		}
+
+		var names []string
+		for _, name := range r.MultipartForm.Value["names"] {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		if action == "remove" && len(names) == 0 && r.FormValue("all") != "true" {
+			writeJSONError(w, "Select the attachments to remove, or remove all of them", http.StatusBadRequest)
+			return
+		}
+
+		uploads := r.MultipartForm.File["attachments"]
+		if action == "add" && len(uploads) == 0 {
+			writeJSONError(w, "No files to attach", http.StatusBadRequest)
+			return
+		}
+
+		// Get uploaded file
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			writeJSONError(w, "No file uploaded", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		// Validate PDF
+		if filepath.Ext(header.Filename) != ".pdf" {
+			writeJSONError(w, "Only PDF files are allowed", http.StatusBadRequest)
+			return
+		}
+
+		// Save uploaded file
+		inputPath := filepath.Join(tmpDir, generateID()+"_input.pdf")
+		if err := saveUploadedFile(file, inputPath); err != nil {
+			log.Printf("Error saving file: %v", err)
+			writeJSONError(w, "Failed to save uploaded file", http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(inputPath)
+
+		switch action {
+		case "list":
+			attachments, err := pdf.ListAttachments(inputPath)
+			if err != nil {
+				log.Printf("Error listing attachments: %v", err)
+				writeJSONError(w, fmt.Sprintf("Failed to list attachments: %v", err), http.StatusBadRequest)
+				return
+			}
+
+			message := fmt.Sprintf("Found %d attachments.", len(attachments))
+			if len(attachments) == 1 {
+				message = "Found 1 attachment."
+			}
+			writeJSON(w, Response{Success: true, Message: message, Attachments: attachments})
+
+		case "add":
+			// Descriptions are matched to the uploaded files by position
+			descriptions := r.MultipartForm.Value["descriptions"]
+			var files []pdf.AttachmentFile
+			for i, upload := range uploads {
+				src, err := upload.Open()
+				if err != nil {
+					writeJSONError(w, "Failed to read uploaded file", http.StatusBadRequest)
+					return
+				}
+				attachmentPath := filepath.Join(tmpDir, fmt.Sprintf("%s_attachment_%d", generateID(), i))
+				err = saveUploadedFile(src, attachmentPath)
+				src.Close()
+				defer os.Remove(attachmentPath)
+				if err != nil {
+					log.Printf("Error saving file: %v", err)
+					writeJSONError(w, "Failed to save uploaded file", http.StatusInternalServerError)
+					return
+				}
+
+				attachment := pdf.AttachmentFile{Path: attachmentPath, Name: filepath.Base(upload.Filename)}
+				if i < len(descriptions) {
+					attachment.Description = strings.TrimSpace(descriptions[i])
+				}
+				files = append(files, attachment)
+			}
+
+			outputPath := filepath.Join(tmpDir, generateID()+"_attached.pdf")
+			if err := pdf.AddAttachments(inputPath, outputPath, files); err != nil {
+				log.Printf("Error adding attachments: %v", err)
+				writeJSONError(w, fmt.Sprintf("Failed to add attachments: %v", err), http.StatusInternalServerError)
+				return
+			}
+
+			downloadURL := fmt.Sprintf("/download/%s", filepath.Base(outputPath))
+			writeJSONSuccess(w, "Files attached successfully.", downloadURL, 0, 0)
+
+		case "extract":
+			outputPath, count, err := pdf.ExtractAttachments(inputPath, tmpDir, names)
+			if err != nil {
+				log.Printf("Error extracting attachments: %v", err)
+				writeJSONError(w, fmt.Sprintf("Failed to extract attachments: %v", err), http.StatusInternalServerError)
+				return
+			}
+
+			downloadURL := fmt.Sprintf("/download/%s", filepath.Base(outputPath))
+
+			message := fmt.Sprintf("Extracted %d attachments.", count)
+			if count == 1 {
+				message = "Extracted 1 attachment."
+			}
+			writeJSONSuccess(w, message, downloadURL, 0, 0)
+
+		case "remove":
+			outputPath := filepath.Join(tmpDir, generateID()+"_detached.pdf")
+			count, err := pdf.RemoveAttachments(inputPath, outputPath, names)
+			if err != nil {
+				log.Printf("Error removing attachments: %v", err)
+				writeJSONError(w, fmt.Sprintf("Failed to remove attachments: %v", err), http.StatusInternalServerError)
+				return
+			}
+
+			downloadURL := fmt.Sprintf("/download/%s", filepath.Base(outputPath))
+
+			message := fmt.Sprintf("Removed %d attachments.", count)
+			if count == 1 {
+				message = "Removed 1 attachment."
+			}
+			writeJSONSuccess(w, message, downloadURL, 0, 0)
+		}
+	}
+}
+
+// HandleFormFields returns the fields of an uploaded PDF form
+func HandleFormFields(tmpDir string, maxMemory int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Parse multipart form
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			writeJSONError(w, "File too large or invalid form data", http.StatusBadRequest)
+			return
+		}
+
+		// Get uploaded file
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			writeJSONError(w, "No file uploaded", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		// Validate PDF
+		if filepath.Ext(header.Filename) != ".pdf" {
+			writeJSONError(w, "Only PDF files are allowed", http.StatusBadRequest)
+			return
+		}
+
+		// Save uploaded file
+		inputPath := filepath.Join(tmpDir, generateID()+"_input.pdf")
+		if err := saveUploadedFile(file, inputPath); err != nil {
+			log.Printf("Error saving file: %v", err)
+			writeJSONError(w, "Failed to save uploaded file", http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(inputPath)
+
+		fields, err := pdf.ListFormFields(inputPath)
+		if err != nil {
+			log.Printf("Error listing form fields: %v", err)
+			writeJSONError(w, fmt.Sprintf("Failed to list form fields: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		writeJSON(w, Response{Success: true, Fields: fields})
+	}
+}
+
+// HandleFormFill fills an uploaded PDF form.
+// The values form value or file holds a JSON object of field names and values. A CSV
+// upload in the data field fills one copy per row instead and returns a ZIP.
+func HandleFormFill(tmpDir string, maxMemory int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Parse multipart form
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			writeJSONError(w, "File too large or invalid form data", http.StatusBadRequest)
+			return
+		}
+
+		// Get uploaded file
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			writeJSONError(w, "No file uploaded", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		// Validate PDF
+		if filepath.Ext(header.Filename) != ".pdf" {
+			writeJSONError(w, "Only PDF files are allowed", http.StatusBadRequest)
+			return
+		}
+
+		data, dataHeader, err := r.FormFile("data")
+		batch := err == nil
+		if batch {
+			defer data.Close()
+			if strings.ToLower(filepath.Ext(dataHeader.Filename)) != ".csv" {
+				writeJSONError(w, "Only CSV files are allowed for batch filling", http.StatusBadRequest)
+				return
+			}
+		}
+
+		var values map[string]string
+		if !batch {
+			// The values may also come as an uploaded JSON file
+			valuesJSON := r.FormValue("values")
+			if valuesFile, _, err := r.FormFile("values"); err == nil {
+				b, err := io.ReadAll(valuesFile)
+				valuesFile.Close()
+				if err != nil {
+					writeJSONError(w, "Failed to read uploaded file", http.StatusBadRequest)
+					return
+				}
+				valuesJSON = string(b)
+			}
+			if values, err = parseFormValues(valuesJSON); err != nil {
+				writeJSONError(w, fmt.Sprintf("Invalid values: %v", err), http.StatusBadRequest)
+				return
+			}
+		}
+
+		// Save uploaded file
+		inputPath := filepath.Join(tmpDir, generateID()+"_input.pdf")
+		if err := saveUploadedFile(file, inputPath); err != nil {
+			log.Printf("Error saving file: %v", err)
+			writeJSONError(w, "Failed to save uploaded file", http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(inputPath)
+
+		if batch {
+			csvPath := filepath.Join(tmpDir, generateID()+"_data.csv")
+			if err := saveUploadedFile(data, csvPath); err != nil {
+				log.Printf("Error saving file: %v", err)
+				writeJSONError(w, "Failed to save uploaded file", http.StatusInternalServerError)
+				return
+			}
+			defer os.Remove(csvPath)
+
+			outputPath, count, err := pdf.FillFormCSV(inputPath, csvPath, tmpDir)
+			if err != nil {
+				log.Printf("Error filling forms: %v", err)
+				writeJSONError(w, fmt.Sprintf("Failed to fill forms: %v", err), http.StatusInternalServerError)
+				return
+			}
+
+			downloadURL := fmt.Sprintf("/download/%s", filepath.Base(outputPath))
+
+			message := fmt.Sprintf("Filled %d forms.", count)
+			if count == 1 {
+				message = "Filled 1 form."
+			}
+			writeJSONSuccess(w, message, downloadURL, 0, 0)
+			return
+		}
+
+		outputPath := filepath.Join(tmpDir, generateID()+"_filled.pdf")
+		if err := pdf.FillForm(inputPath, outputPath, values); err != nil {
+			log.Printf("Error filling form: %v", err)
+			writeJSONError(w, fmt.Sprintf("Failed to fill form: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// Generate download URL
+		downloadURL := fmt.Sprintf("/download/%s", filepath.Base(outputPath))
+
+		writeJSONSuccess(w, "Form filled successfully.", downloadURL, 0, 0)
+	}
+}
+
+// parseFormValues parses a JSON object of form field values. Besides strings it
+// takes booleans for check boxes, numbers, and arrays for list boxes.
+func parseFormValues(value string) (map[string]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, fmt.Errorf("no values given")
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal([]byte(value), &raw); err != nil {
+		return nil, fmt.Errorf("expected a JSON object of field names and values")
+	}
+
+	values := map[string]string{}
+	for name, v := range raw {
+		switch v := v.(type) {
+		case string:
+			values[name] = v
+		case bool:
+			values[name] = strconv.FormatBool(v)
+		case float64:
+			values[name] = strconv.FormatFloat(v, 'f', -1, 64)
+		case []any:
+			var items []string
+			for _, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("%s: list values must be strings", name)
+				}
+				items = append(items, s)
+			}
+			values[name] = strings.Join(items, ",")
+		default:
+			return nil, fmt.Errorf("%s: unsupported value", name)
+		}
+	}
+	return values, nil
+}
+
+// HandleFlatten turns the form fields and annotations of a PDF into page content.
+// The mode form value is "both" (the default), "forms" or "annotations".
+func HandleFlatten(tmpDir string, maxMemory int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Parse multipart form
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			writeJSONError(w, "File too large or invalid form data", http.StatusBadRequest)
+			return
+		}
+
+		// Get uploaded file
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			writeJSONError(w, "No file uploaded", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		// Validate PDF
+		if filepath.Ext(header.Filename) != ".pdf" {
+			writeJSONError(w, "Only PDF files are allowed", http.StatusBadRequest)
+			return
+		}
+
+		var opts pdf.FlattenOptions
+		switch mode := r.FormValue("mode"); mode {
+		case "", "both":
+			opts = pdf.FlattenOptions{Forms: true, Annotations: true}
+		case "forms":
+			opts = pdf.FlattenOptions{Forms: true}
+		case "annotations":
+			opts = pdf.FlattenOptions{Annotations: true}
+		default:
+			writeJSONError(w, fmt.Sprintf("Unknown mode %q", mode), http.StatusBadRequest)
+			return
+		}
+
+		// Save uploaded file
+		inputPath := filepath.Join(tmpDir, generateID()+"_input.pdf")
+		if err := saveUploadedFile(file, inputPath); err != nil {
+			log.Printf("Error saving file: %v", err)
+			writeJSONError(w, "Failed to save uploaded file", http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(inputPath)
+
+		outputPath := filepath.Join(tmpDir, generateID()+"_flattened.pdf")
+		result, err := pdf.Flatten(inputPath, outputPath, opts)
+		if err != nil {
+			log.Printf("Error flattening PDF: %v", err)
+			writeJSONError(w, fmt.Sprintf("Failed to flatten PDF: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// Generate download URL
+		downloadURL := fmt.Sprintf("/download/%s", filepath.Base(outputPath))
+
+		var parts []string
+		if opts.Forms {
+			fields := fmt.Sprintf("%d form fields", result.Fields)
+			if result.Fields == 1 {
+				fields = "1 form field"
+			}
+			parts = append(parts, fields)
+		}
+		if opts.Annotations {
+			annotations := fmt.Sprintf("%d annotations", result.Annotations)
+			if result.Annotations == 1 {
+				annotations = "1 annotation"
+			}
+			parts = append(parts, annotations)
+		}
+		writeJSONSuccess(w, fmt.Sprintf("Flattened %s.", strings.Join(parts, " and ")), downloadURL, 0, 0)
+	}
+}
+
+// HandleAnnotations lists or removes the annotations of a PDF.
+// The action form value selects "list" (the default) or "remove". Remove takes
+// the annotations matching all of pageRange, the types values and the authors
+// values; without any of them it needs all=true.
+func HandleAnnotations(tmpDir string, maxMemory int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Parse multipart form
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			writeJSONError(w, "File too large or invalid form data", http.StatusBadRequest)
+			return
+		}
+
+		action := r.FormValue("action")
+		if action == "" {
+			action = "list"
+		}
+		if action != "list" && action != "remove" {
+			writeJSONError(w, "Invalid action", http.StatusBadRequest)
+			return
+		}
+
+		filter := pdf.AnnotationFilter{Pages: strings.TrimSpace(r.FormValue("pageRange"))}
+		for _, t := range r.MultipartForm.Value["types"] {
+			if t = strings.TrimSpace(t); t != "" {
+				filter.Types = append(filter.Types, t)
+			}
+		}
+		for _, author := range r.MultipartForm.Value["authors"] {
+			if author = strings.TrimSpace(author); author != "" {
+				filter.Authors = append(filter.Authors, author)
+			}
+		}
+		if action == "remove" && filter.Pages == "" && len(filter.Types) == 0 && len(filter.Authors) == 0 && r.FormValue("all") != "true" {
+			writeJSONError(w, "Select the annotations to remove, or remove all of them", http.StatusBadRequest)
+			return
+		}
+
+		// Get uploaded file
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			writeJSONError(w, "No file uploaded", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		// Validate PDF
+		if filepath.Ext(header.Filename) != ".pdf" {
+			writeJSONError(w, "Only PDF files are allowed", http.StatusBadRequest)
+			return
+		}
+
+		// Save uploaded file
+		inputPath := filepath.Join(tmpDir, generateID()+"_input.pdf")
+		if err := saveUploadedFile(file, inputPath); err != nil {
+			log.Printf("Error saving file: %v", err)
+			writeJSONError(w, "Failed to save uploaded file", http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(inputPath)
+
+		if action == "list" {
+			annotations, err := pdf.ListAnnotations(inputPath)
+			if err != nil {
+				log.Printf("Error listing annotations: %v", err)
+				writeJSONError(w, fmt.Sprintf("Failed to list annotations: %v", err), http.StatusBadRequest)
+				return
+			}
+
+			message := fmt.Sprintf("Found %d annotations.", len(annotations))
+			if len(annotations) == 1 {
+				message = "Found 1 annotation."
+			}
+			writeJSON(w, Response{Success: true, Message: message, Annotations: annotations})
+			return
+		}
+
+		outputPath := filepath.Join(tmpDir, generateID()+"_cleaned.pdf")
+		count, err := pdf.RemoveAnnotations(inputPath, outputPath, filter)
+		if err != nil {
+			log.Printf("Error removing annotations: %v", err)
+			writeJSONError(w, fmt.Sprintf("Failed to remove annotations: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// Generate download URL
+		downloadURL := fmt.Sprintf("/download/%s", filepath.Base(outputPath))
+
+		message := fmt.Sprintf("Removed %d annotations.", count)
+		if count == 1 {
+			message = "Removed 1 annotation."
+		}
+		writeJSONSuccess(w, message, downloadURL, 0, 0)
+	}
+}
+
+// HandleBookmarks exports or imports the outline of a PDF.
+// The action form value selects "export" (the default) or "import". Import takes
+// the bookmarks form value or file, a JSON array or an exported response, and
+// mode "replace" (the default) or "append".
+func HandleBookmarks(tmpDir string, maxMemory int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Parse multipart form
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			writeJSONError(w, "File too large or invalid form data", http.StatusBadRequest)
+			return
+		}
+
+		action := r.FormValue("action")
+		if action == "" {
+			action = "export"
+		}
+		if action != "export" && action != "import" {
+			writeJSONError(w, "Invalid action", http.StatusBadRequest)
+			return
+		}
+
+		mode := r.FormValue("mode")
+		if mode == "" {
+			mode = "replace"
+		}
+		if mode != "replace" && mode != "append" {
+			writeJSONError(w, fmt.Sprintf("Unknown mode %q", mode), http.StatusBadRequest)
+			return
+		}
+
+		var bookmarks []pdf.Bookmark
+		if action == "import" {
+			// The bookmarks may also come as an uploaded JSON file
+			bookmarksJSON := r.FormValue("bookmarks")
+			if bookmarksFile, _, err := r.FormFile("bookmarks"); err == nil {
+				b, err := io.ReadAll(bookmarksFile)
+				bookmarksFile.Close()
+				if err != nil {
+					writeJSONError(w, "Failed to read uploaded file", http.StatusBadRequest)
+					return
+				}
+				bookmarksJSON = string(b)
+			}
+			var err error
+			if bookmarks, err = parseBookmarks(bookmarksJSON); err != nil {
+				writeJSONError(w, fmt.Sprintf("Invalid bookmarks: %v", err), http.StatusBadRequest)
+				return
+			}
+		}
+
+		// Get uploaded file
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			writeJSONError(w, "No file uploaded", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		// Validate PDF
+		if filepath.Ext(header.Filename) != ".pdf" {
+			writeJSONError(w, "Only PDF files are allowed", http.StatusBadRequest)
+			return
+		}
+
+		// Save uploaded file
+		inputPath := filepath.Join(tmpDir, generateID()+"_input.pdf")
+		if err := saveUploadedFile(file, inputPath); err != nil {
+			log.Printf("Error saving file: %v", err)
+			writeJSONError(w, "Failed to save uploaded file", http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(inputPath)
+
+		if action == "export" {
+			bookmarks, err := pdf.ExportBookmarks(inputPath)
+			if err != nil {
+				log.Printf("Error exporting bookmarks: %v", err)
+				writeJSONError(w, fmt.Sprintf("Failed to export bookmarks: %v", err), http.StatusBadRequest)
+				return
+			}
+
+			message := "The PDF has no bookmarks."
+			if len(bookmarks) > 0 {
+				message = "Bookmarks exported successfully."
+			}
+			writeJSON(w, Response{Success: true, Message: message, Bookmarks: bookmarks})
+			return
+		}
+
+		outputPath := filepath.Join(tmpDir, generateID()+"_bookmarked.pdf")
+		if err := pdf.ImportBookmarks(inputPath, outputPath, bookmarks, mode == "replace"); err != nil {
+			log.Printf("Error importing bookmarks: %v", err)
+			writeJSONError(w, fmt.Sprintf("Failed to import bookmarks: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// Generate download URL
+		downloadURL := fmt.Sprintf("/download/%s", filepath.Base(outputPath))
+
+		writeJSONSuccess(w, "Bookmarks imported successfully.", downloadURL, 0, 0)
+	}
+}
+
+// parseBookmarks parses a JSON array of bookmarks, or an object holding them
+// in bookmarks as returned by an export
+func parseBookmarks(value string) ([]pdf.Bookmark, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, fmt.Errorf("no bookmarks given")
+	}
+
+	var bookmarks []pdf.Bookmark
+	if strings.HasPrefix(value, "{") {
+		var exported struct {
+			Bookmarks []pdf.Bookmark `json:"bookmarks"`
+		}
+		if err := json.Unmarshal([]byte(value), &exported); err != nil {
+			return nil, fmt.Errorf("expected a JSON array of bookmarks")
+		}
+		bookmarks = exported.Bookmarks
+	} else if err := json.Unmarshal([]byte(value), &bookmarks); err != nil {
+		return nil, fmt.Errorf("expected a JSON array of bookmarks")
+	}
+
+	if len(bookmarks) == 0 {
+		return nil, fmt.Errorf("no bookmarks given")
+	}
+	return bookmarks, nil
+}
+
+// HandleDownload handles file download requests
+func HandleDownload(tmpDir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filename := filepath.Base(r.URL.Path)
+		filePath := filepath.Join(tmpDir, filename)
+
+		// Security check: ensure file is in tmp directory
+		absPath, err := filepath.Abs(filePath)
+		if err != nil {
+			http.Error(w, "Invalid file path", http.StatusBadRequest)
+			return
+		}
+
+		absTmpDir, err := filepath.Abs(tmpDir)
+		if err != nil {
+			http.Error(w, "Invalid tmp directory", http.StatusInternalServerError)
+			return
+		}
+
+		if !filepath.HasPrefix(absPath, absTmpDir) {
+			http.Error(w, "Access denied", http.StatusForbidden)
+			return
+		}
+
+		// Check if file exists
+		if _, err := os.Stat(filePath); os.IsNotExist(err) {
+			http.Error(w, "File not found", http.StatusNotFound)
+			return
+		}
+
+		// Serve file
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+		http.ServeFile(w, r, filePath)
+	}
+}
+
+// Helper functions
+
+func renderTemplate(w http.ResponseWriter, tmpl string) {
+	t, err := template.ParseFiles(filepath.Join("web", "templates", tmpl))
+	if err != nil {
+		log.Printf("Error parsing template: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := t.Execute(w, nil); err != nil {
+		log.Printf("Error executing template: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func writeJSONSuccess(w http.ResponseWriter, message, downloadURL string, originalSize, compressedSize int64) {
+	writeJSON(w, Response{
+		Success:        true,
+		Message:        message,
+		DownloadURL:    downloadURL,
+		OriginalSize:   originalSize,
+		CompressedSize: compressedSize,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(Response{
 		Success: false,
 		Error:   message,
 	})
@@ -850,6 +2675,46 @@ func saveUploadedFile(src io.Reader, dst string) error {
 	return err
 }
 
+// saveUploadedPDFs saves multi-file uploads to tmpDir in order.
+// On failure it writes the error response, removes anything already saved and returns false.
+func saveUploadedPDFs(w http.ResponseWriter, files []*multipart.FileHeader, tmpDir string) ([]string, bool) {
+	var inputPaths []string
+	cleanup := func() {
+		for _, path := range inputPaths {
+			os.Remove(path)
+		}
+	}
+
+	for i, fileHeader := range files {
+		if filepath.Ext(fileHeader.Filename) != ".pdf" {
+			cleanup()
+			writeJSONError(w, "Only PDF files are allowed", http.StatusBadRequest)
+			return nil, false
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			cleanup()
+			writeJSONError(w, "Failed to read uploaded file", http.StatusBadRequest)
+			return nil, false
+		}
+
+		inputPath := filepath.Join(tmpDir, fmt.Sprintf("%s_input_%d.pdf", generateID(), i))
+		err = saveUploadedFile(file, inputPath)
+		file.Close()
+		if err != nil {
+			log.Printf("Error saving file: %v", err)
+			os.Remove(inputPath)
+			cleanup()
+			writeJSONError(w, "Failed to save uploaded file", http.StatusInternalServerError)
+			return nil, false
+		}
+		inputPaths = append(inputPaths, inputPath)
+	}
+
+	return inputPaths, true
+}
+
 func generateID() string {
 	b := make([]byte, 16)
 	rand.Read(b)
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"lovepdf/internal/pdf"
+)
+
+// infoPDF returns a one-page PDF whose information dictionary has a title,
+// an author, keywords and a creation date
+func infoPDF() []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		"<< /Title (Old title) /Author (Jane Roe) /Keywords (tax, 2023) /CreationDate (D:20230102030405Z) >>",
+	}
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(objects))
+	for i, o := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R /Info 4 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+// postMetadata posts infoPDF and fields to HandleMetadata and returns the metadata of the result
+func postMetadata(t *testing.T, fields map[string]string) pdf.Metadata {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", "doc.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(infoPDF())
+	mw.WriteField("action", "write")
+	for key, value := range fields {
+		mw.WriteField(key, value)
+	}
+	mw.Close()
+
+	tmpDir := t.TempDir()
+	req := httptest.NewRequest(http.MethodPost, "/api/metadata", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	HandleMetadata(tmpDir, 10<<20)(rec, req)
+
+	var resp Response
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || !resp.Success {
+		t.Fatalf("HandleMetadata = %d %+v, %v", rec.Code, resp, err)
+	}
+
+	md, err := pdf.ReadMetadata(filepath.Join(tmpDir, filepath.Base(resp.DownloadURL)))
+	if err != nil {
+		t.Fatalf("ReadMetadata error: %v", err)
+	}
+	return md
+}
+
+func TestHandleMetadataKeepsFieldsNotSent(t *testing.T) {
+	md := postMetadata(t, map[string]string{"title": "Foo"})
+	if md.Title != "Foo" {
+		t.Errorf("Title = %q, want %q", md.Title, "Foo")
+	}
+	if md.Author != "Jane Roe" || md.Keywords != "tax, 2023" {
+		t.Errorf("Author, Keywords = %q, %q, want them kept", md.Author, md.Keywords)
+	}
+	if md.CreationDate == nil || md.CreationDate.Year() != 2023 {
+		t.Errorf("CreationDate = %v, want it kept", md.CreationDate)
+	}
+}
+
+func TestHandleMetadataClearsFieldsSentEmpty(t *testing.T) {
+	md := postMetadata(t, map[string]string{"author": "", "creationDate": ""})
+	if md.Author != "" || md.CreationDate != nil {
+		t.Errorf("Author, CreationDate = %q, %v, want them cleared", md.Author, md.CreationDate)
+	}
+	if md.Title != "Old title" || md.Keywords != "tax, 2023" {
+		t.Errorf("Title, Keywords = %q, %q, want them kept", md.Title, md.Keywords)
+	}
+}
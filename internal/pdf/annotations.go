@@ -0,0 +1,225 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Annotation describes an annotation on a page
+type Annotation struct {
+	Page     int        `json:"page"`
+	Type     string     `json:"type"` // e.g. "Text" for sticky notes, "Highlight", "FreeText", "Link" or "Widget" for form fields
+	Author   string     `json:"author,omitempty"`
+	Contents string     `json:"contents,omitempty"`
+	Rect     []float64  `json:"rect"` // Lower left and upper right corner in points
+	Modified *time.Time `json:"modified,omitempty"`
+}
+
+// AnnotationFilter selects annotations by page, type and author. Empty criteria
+// match everything; an annotation must match all criteria that are set.
+type AnnotationFilter struct {
+	Pages   string   // Page selection like "1-3,last"
+	Types   []string // Annotation types, compared case-insensitively
+	Authors []string // Authors, compared case-insensitively
+}
+
+// ListAnnotations returns the annotations of a PDF file page by page.
+// Pop-up windows are left out, as they only show the text of another annotation.
+func ListAnnotations(inputPath string) ([]Annotation, error) {
+	ctx, err := api.ReadContextFile(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	annotations := []Annotation{}
+	for page := 1; page <= ctx.PageCount; page++ {
+		pageDict, _, _, err := ctx.PageDict(page, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read page %d: %w", page, err)
+		}
+		annots, err := ctx.DereferenceArray(pageDict["Annots"])
+		if err != nil {
+			continue
+		}
+
+		for _, o := range annots {
+			annot, err := ctx.DereferenceDict(o)
+			if err != nil || annot == nil {
+				continue
+			}
+			a := readAnnotation(ctx, annot)
+			if a.Type == "Popup" {
+				continue
+			}
+			a.Page = page
+			annotations = append(annotations, a)
+		}
+	}
+
+	return annotations, nil
+}
+
+// RemoveAnnotations writes a copy of a PDF file without the annotations that
+// match filter and returns how many were removed. Their pop-up windows and
+// replies go with them. Form fields are never removed, as that would break
+// the form; flatten or fill it instead.
+func RemoveAnnotations(inputPath, outputPath string, filter AnnotationFilter) (int, error) {
+	ctx, err := api.ReadContextFile(inputPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	pages, err := selectPages(filter.Pages, ctx.PageCount)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, page := range pages {
+		n, err := removePageAnnotations(ctx, page, filter)
+		if err != nil {
+			return 0, fmt.Errorf("failed to remove annotations from page %d: %w", page, err)
+		}
+		removed += n
+	}
+	if removed == 0 {
+		return 0, fmt.Errorf("no annotations match")
+	}
+
+	if err := api.WriteContextFile(ctx, outputPath); err != nil {
+		return 0, fmt.Errorf("failed to write PDF: %w", err)
+	}
+
+	return removed, nil
+}
+
+// removePageAnnotations removes the annotations of a page that match filter and
+// returns how many were removed, not counting pop-ups and replies
+func removePageAnnotations(ctx *model.Context, page int, filter AnnotationFilter) (int, error) {
+	pageDict, _, _, err := ctx.PageDict(page, false)
+	if err != nil {
+		return 0, err
+	}
+	annots, err := ctx.DereferenceArray(pageDict["Annots"])
+	if err != nil || len(annots) == 0 {
+		return 0, nil
+	}
+
+	dicts := make([]types.Dict, len(annots))
+	remove := make([]bool, len(annots))
+	removedRefs := map[int]bool{}
+	removed := 0
+	for i, o := range annots {
+		annot, err := ctx.DereferenceDict(o)
+		if err != nil || annot == nil {
+			continue
+		}
+		dicts[i] = annot
+
+		a := readAnnotation(ctx, annot)
+		if a.Type == "Widget" || a.Type == "Popup" || !filter.matches(a) {
+			continue
+		}
+		remove[i] = true
+		removed++
+		if ref, ok := o.(types.IndirectRef); ok {
+			removedRefs[ref.ObjectNumber.Value()] = true
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+
+	// Replies point to the annotation they answer and may themselves be
+	// answered, so repeat until no more annotations lose their parent
+	for changed := true; changed; {
+		changed = false
+		for i, o := range annots {
+			if remove[i] || dicts[i] == nil {
+				continue
+			}
+			parent := dicts[i]["Parent"]
+			if s := dicts[i].NameEntry("Subtype"); s == nil || *s != "Popup" {
+				parent = dicts[i]["IRT"]
+			}
+			if ref, ok := parent.(types.IndirectRef); ok && removedRefs[ref.ObjectNumber.Value()] {
+				remove[i] = true
+				changed = true
+				if ref, ok := o.(types.IndirectRef); ok {
+					removedRefs[ref.ObjectNumber.Value()] = true
+				}
+			}
+		}
+	}
+
+	var kept types.Array
+	for i, o := range annots {
+		if !remove[i] {
+			kept = append(kept, o)
+		}
+	}
+	if len(kept) > 0 {
+		pageDict["Annots"] = kept
+	} else {
+		delete(pageDict, "Annots")
+	}
+
+	return removed, nil
+}
+
+// matches reports whether a meets all criteria of the filter other than its pages
+func (f AnnotationFilter) matches(a Annotation) bool {
+	if len(f.Types) > 0 && !containsFold(f.Types, a.Type) {
+		return false
+	}
+	if len(f.Authors) > 0 && !containsFold(f.Authors, a.Author) {
+		return false
+	}
+	return true
+}
+
+// containsFold reports whether values contains s, ignoring case and surrounding space
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), strings.TrimSpace(s)) {
+			return true
+		}
+	}
+	return false
+}
+
+// readAnnotation reads the type, author, contents, rectangle and modification date of an annotation
+func readAnnotation(ctx *model.Context, annot types.Dict) Annotation {
+	a := Annotation{Rect: numberArray(ctx, annot["Rect"])}
+	if s := annot.NameEntry("Subtype"); s != nil {
+		a.Type = *s
+	}
+	if a.Rect == nil {
+		a.Rect = []float64{}
+	}
+
+	text := func(key string) string {
+		s, err := ctx.DereferenceStringOrHexLiteral(annot[key], model.V10, nil)
+		if err != nil {
+			return ""
+		}
+		return s
+	}
+	// Form fields keep their name rather than an author in T
+	if a.Type != "Widget" {
+		a.Author = text("T")
+	}
+	a.Contents = text("Contents")
+	if m := text("M"); m != "" {
+		if t, ok := types.DateTime(m, true); ok {
+			a.Modified = &t
+		}
+	}
+
+	return a
+}
@@ -0,0 +1,265 @@
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// Attachment describes a file embedded in a PDF
+type Attachment struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Size        int64      `json:"size"` // Size of the file in bytes
+	ModTime     *time.Time `json:"modTime,omitempty"`
+}
+
+// AttachmentFile is a file to embed in a PDF
+type AttachmentFile struct {
+	Path        string // File to embed
+	Name        string // Name of the attachment; empty for the base name of Path
+	Description string
+}
+
+// ListAttachments returns the files embedded in a PDF file
+func ListAttachments(inputPath string) ([]Attachment, error) {
+	ctx, err := readAttachmentsContext(inputPath, model.LISTATTACHMENTS)
+	if err != nil {
+		return nil, err
+	}
+
+	embedded, err := embeddedFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments := []Attachment{}
+	for _, a := range embedded {
+		// pdfcpu has no size for attachments, so count the decoded content
+		size, err := io.Copy(io.Discard, a.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read attachment %s: %w", attachmentName(a), err)
+		}
+		attachments = append(attachments, Attachment{
+			Name:        attachmentName(a),
+			Description: a.Desc,
+			Size:        size,
+			ModTime:     a.ModTime,
+		})
+	}
+
+	return attachments, nil
+}
+
+// AddAttachments writes a copy of a PDF file with files embedded in it.
+// A name that is already attached is an error rather than a silent replacement.
+func AddAttachments(inputPath, outputPath string, files []AttachmentFile) error {
+	if len(files) == 0 {
+		return fmt.Errorf("no files to attach")
+	}
+
+	ctx, err := readAttachmentsContext(inputPath, model.ADDATTACHMENTS)
+	if err != nil {
+		return err
+	}
+
+	embedded, err := embeddedFiles(ctx)
+	if err != nil {
+		return err
+	}
+	used := map[string]bool{}
+	for _, a := range embedded {
+		used[a.ID] = true
+		used[attachmentName(a)] = true
+	}
+
+	for _, file := range files {
+		name := file.Name
+		if name == "" {
+			name = filepath.Base(file.Path)
+		}
+		if used[name] {
+			return fmt.Errorf("the PDF already has an attachment named %q", name)
+		}
+		used[name] = true
+
+		if err := addAttachment(ctx, file.Path, name, file.Description); err != nil {
+			return fmt.Errorf("failed to attach %s: %w", name, err)
+		}
+	}
+
+	if err := api.WriteContextFile(ctx, outputPath); err != nil {
+		return fmt.Errorf("failed to write PDF: %w", err)
+	}
+
+	return nil
+}
+
+// addAttachment embeds a file under name
+func addAttachment(ctx *model.Context, filePath, name, description string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	modTime := fi.ModTime()
+
+	return ctx.AddAttachment(model.Attachment{Reader: f, ID: name, Desc: description, ModTime: &modTime}, false)
+}
+
+// ExtractAttachments writes the named attachments of a PDF file to a ZIP and
+// returns its path and the number of files in it. No names extracts them all.
+func ExtractAttachments(inputPath, outputDir string, names []string) (string, int, error) {
+	ctx, err := readAttachmentsContext(inputPath, model.EXTRACTATTACHMENTS)
+	if err != nil {
+		return "", 0, err
+	}
+
+	embedded, err := embeddedFiles(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	selected, err := selectAttachments(embedded, names)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(selected) == 0 {
+		return "", 0, fmt.Errorf("the PDF has no attachments")
+	}
+
+	attachmentsDir, err := os.MkdirTemp(outputDir, "attachments_")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	defer os.RemoveAll(attachmentsDir)
+
+	// Names come from the document, so keep only their last element and make
+	// them unique within the ZIP
+	used := map[string]bool{}
+	for _, a := range selected {
+		name := strings.ReplaceAll(attachmentName(a), "\\", "/")
+		name = uniqueFileName(path.Base(name), used)
+		if err := pdfcpu.WriteReader(filepath.Join(attachmentsDir, name), a.Reader); err != nil {
+			return "", 0, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	zipPath := filepath.Join(outputDir, filepath.Base(attachmentsDir)+".zip")
+	if err := zipDirectory(attachmentsDir, zipPath); err != nil {
+		os.Remove(zipPath)
+		return "", 0, fmt.Errorf("failed to create ZIP: %w", err)
+	}
+
+	return zipPath, len(selected), nil
+}
+
+// RemoveAttachments writes a copy of a PDF file without the named attachments
+// and returns how many were removed. No names removes them all.
+func RemoveAttachments(inputPath, outputPath string, names []string) (int, error) {
+	ctx, err := readAttachmentsContext(inputPath, model.REMOVEATTACHMENTS)
+	if err != nil {
+		return 0, err
+	}
+
+	embedded, err := embeddedFiles(ctx)
+	if err != nil {
+		return 0, err
+	}
+	selected, err := selectAttachments(embedded, names)
+	if err != nil {
+		return 0, err
+	}
+	if len(selected) == 0 {
+		return 0, fmt.Errorf("the PDF has no attachments")
+	}
+
+	var ids []string
+	if len(selected) < len(embedded) {
+		for _, a := range selected {
+			ids = append(ids, a.ID)
+		}
+	}
+	if _, err := ctx.RemoveAttachments(ids); err != nil {
+		return 0, fmt.Errorf("failed to remove attachments: %w", err)
+	}
+
+	if err := api.WriteContextFile(ctx, outputPath); err != nil {
+		return 0, fmt.Errorf("failed to write PDF: %w", err)
+	}
+
+	return len(selected), nil
+}
+
+// readAttachmentsContext reads and validates a PDF file for an attachment command
+func readAttachmentsContext(inputPath string, cmd model.CommandMode) (*model.Context, error) {
+	conf := model.NewDefaultConfiguration()
+	conf.Cmd = cmd
+
+	ctx, err := readValidateAndOptimizeFile(inputPath, conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	return ctx, nil
+}
+
+// embeddedFiles returns every attachment of ctx with its content
+func embeddedFiles(ctx *model.Context) ([]model.Attachment, error) {
+	if ctx.Names["EmbeddedFiles"] == nil {
+		return nil, nil
+	}
+
+	embedded, err := ctx.ExtractAttachments(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachments: %w", err)
+	}
+	return embedded, nil
+}
+
+// selectAttachments returns the attachments matching names, or all of them for no names.
+// A name matches the file name of an attachment or its key in the document.
+func selectAttachments(embedded []model.Attachment, names []string) ([]model.Attachment, error) {
+	if len(names) == 0 {
+		return embedded, nil
+	}
+
+	var selected []model.Attachment
+	done := map[string]bool{}
+	for _, name := range names {
+		found := false
+		for _, a := range embedded {
+			if a.ID == name || a.FileName == name {
+				if !done[a.ID] {
+					selected = append(selected, a)
+					done[a.ID] = true
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("attachment %q not found", name)
+		}
+	}
+	return selected, nil
+}
+
+// attachmentName returns the file name of an attachment, falling back to its key
+func attachmentName(a model.Attachment) string {
+	if a.FileName != "" {
+		return a.FileName
+	}
+	return a.ID
+}
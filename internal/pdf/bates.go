@@ -0,0 +1,121 @@
+package pdf
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// BatesOptions describes a Bates label like "ABC000123" continued across several documents
+type BatesOptions struct {
+	Prefix   string // Text printed before the counter, e.g. "ABC"
+	Suffix   string // Text printed after the counter
+	Digits   int    // Zero-pad the counter to this many digits
+	Start    int    // Number stamped on the first page of the first document
+	Position string // Anchor: tl, tc, tr, bl, bc, br
+	FontName string // "Helvetica", "Times-Roman" or "Courier"
+	FontSize int    // Font size in points
+	Color    string // Text color as "#rrggbb"
+}
+
+// BatesSource is an input document and the file name to use for it in the output
+type BatesSource struct {
+	Path string
+	Name string
+}
+
+// BatesStamp stamps consecutive Bates numbers across the pages of all sources, in order.
+// It returns the path to a ZIP holding the stamped files and an index.csv that maps
+// each file to its first and last Bates number.
+func BatesStamp(sources []BatesSource, outputDir string, opts BatesOptions) (string, error) {
+	if len(sources) == 0 {
+		return "", fmt.Errorf("no PDF files provided")
+	}
+
+	batesDir, err := os.MkdirTemp(outputDir, "bates_")
+	if err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+	defer os.RemoveAll(batesDir)
+
+	index := [][]string{{"file", "first", "last"}}
+	used := map[string]bool{"index.csv": true}
+	next := opts.Start
+
+	for _, src := range sources {
+		pageCount, err := PageCount(src.Path)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", src.Name, err)
+		}
+
+		name := uniqueFileName(src.Name, used)
+		err = AddPageNumbers(src.Path, filepath.Join(batesDir, name), PageNumberOptions{
+			Format:   opts.Prefix + "{n}" + opts.Suffix,
+			Position: opts.Position,
+			Start:    next,
+			Digits:   opts.Digits,
+			FontName: opts.FontName,
+			FontSize: opts.FontSize,
+			Color:    opts.Color,
+		})
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", src.Name, err)
+		}
+
+		last := next + pageCount - 1
+		index = append(index, []string{name, batesLabel(next, opts), batesLabel(last, opts)})
+		next = last + 1
+	}
+
+	if err := writeCSV(filepath.Join(batesDir, "index.csv"), index); err != nil {
+		return "", fmt.Errorf("failed to write index: %w", err)
+	}
+
+	zipPath := filepath.Join(outputDir, filepath.Base(batesDir)+".zip")
+	if err := zipDirectory(batesDir, zipPath); err != nil {
+		os.Remove(zipPath)
+		return "", fmt.Errorf("failed to create ZIP: %w", err)
+	}
+
+	return zipPath, nil
+}
+
+// batesLabel formats a single Bates number
+func batesLabel(n int, opts BatesOptions) string {
+	return opts.Prefix + fmt.Sprintf("%0*d", opts.Digits, n) + opts.Suffix
+}
+
+// uniqueFileName returns a base name for name that is not in used yet and records it
+func uniqueFileName(name string, used map[string]bool) string {
+	base := filepath.Base(name)
+	if base == "." || base == string(filepath.Separator) {
+		base = "document.pdf"
+	}
+
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	candidate := base
+	for i := 2; used[candidate]; i++ {
+		candidate = stem + "_" + strconv.Itoa(i) + ext
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// writeCSV writes records to a new CSV file
+func writeCSV(path string, records [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	if err := w.WriteAll(records); err != nil {
+		return err
+	}
+	return f.Close()
+}
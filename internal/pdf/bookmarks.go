@@ -0,0 +1,192 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// Bookmark is an entry of a PDF's outline, the table of contents viewers show in a side panel
+type Bookmark struct {
+	Title    string     `json:"title"`
+	Page     int        `json:"page"`
+	Level    int        `json:"level,omitempty"` // Depth in the outline, 1 for top-level entries
+	Children []Bookmark `json:"children,omitempty"`
+}
+
+// ExportBookmarks returns the outline of a PDF file as a tree of bookmarks.
+// Entries that do not point to a page of the document, such as links to
+// websites or broken destinations, are left out and replaced by their children.
+func ExportBookmarks(inputPath string) ([]Bookmark, error) {
+	ctx, err := readBookmarksContext(inputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	bms, err := pdfcpu.Bookmarks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bookmarks: %w", err)
+	}
+
+	// Broken destinations resolve to page 0, which could not be imported again
+	return exportBookmarks(pageBookmarks(bms, ctx.PageCount), 1), nil
+}
+
+// exportBookmarks converts pdfcpu's bookmarks at the given level
+func exportBookmarks(bms []pdfcpu.Bookmark, level int) []Bookmark {
+	result := []Bookmark{}
+	for _, bm := range bms {
+		result = append(result, Bookmark{
+			Title:    bm.Title,
+			Page:     bm.PageFrom,
+			Level:    level,
+			Children: exportBookmarks(bm.Kids, level+1),
+		})
+	}
+	return result
+}
+
+// ImportBookmarks writes a copy of a PDF file with the given bookmarks, either
+// replacing its outline or appended to it. Bookmarks nest through their
+// children, or through their level in a flat list: an entry one level deeper
+// than the one before it is its child. Outlines must be in page order, so
+// siblings are sorted by page and a parent moves up to its first child if
+// that comes earlier. Appending rewrites the existing bookmarks to point to
+// the top of their page and drops those that do not point to a page.
+func ImportBookmarks(inputPath, outputPath string, bookmarks []Bookmark, replace bool) error {
+	if len(bookmarks) == 0 {
+		return fmt.Errorf("no bookmarks to import")
+	}
+
+	ctx, err := readBookmarksContext(inputPath)
+	if err != nil {
+		return err
+	}
+
+	var entries []flatBookmark
+	if err := flattenBookmarks(bookmarks, 1, ctx.PageCount, &entries); err != nil {
+		return err
+	}
+	i := 0
+	bms, err := nestBookmarks(entries, &i, 1)
+	if err != nil {
+		return err
+	}
+
+	if !replace {
+		existing, err := pdfcpu.Bookmarks(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read bookmarks: %w", err)
+		}
+		bms = append(pageBookmarks(existing, ctx.PageCount), bms...)
+	}
+	sortBookmarks(bms)
+
+	if err := pdfcpu.AddBookmarks(ctx, bms, true); err != nil {
+		return fmt.Errorf("failed to write bookmarks: %w", err)
+	}
+
+	if err := api.WriteContextFile(ctx, outputPath); err != nil {
+		return fmt.Errorf("failed to write PDF: %w", err)
+	}
+
+	return nil
+}
+
+// flatBookmark is a bookmark with its absolute level, in outline order
+type flatBookmark struct {
+	title string
+	page  int
+	level int
+}
+
+// flattenBookmarks checks bookmarks and appends them with their children to entries.
+// Bookmarks without a level are at level, the one below their parent.
+func flattenBookmarks(bookmarks []Bookmark, level, pageCount int, entries *[]flatBookmark) error {
+	for _, bm := range bookmarks {
+		title := strings.TrimSpace(bm.Title)
+		if title == "" {
+			return fmt.Errorf("a bookmark has no title")
+		}
+		if bm.Page < 1 || bm.Page > pageCount {
+			return fmt.Errorf("bookmark %q: page %d is out of range (document has %d pages)", title, bm.Page, pageCount)
+		}
+
+		entry := flatBookmark{title: title, page: bm.Page, level: level}
+		if bm.Level != 0 {
+			if bm.Level < level {
+				return fmt.Errorf("bookmark %q: level %d is above its parent's", title, bm.Level)
+			}
+			entry.level = bm.Level
+		}
+		*entries = append(*entries, entry)
+
+		if err := flattenBookmarks(bm.Children, entry.level+1, pageCount, entries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nestBookmarks builds the bookmarks at level from entries, starting at entries[*i],
+// and advances *i past them and their descendants
+func nestBookmarks(entries []flatBookmark, i *int, level int) ([]pdfcpu.Bookmark, error) {
+	var bms []pdfcpu.Bookmark
+	for *i < len(entries) && entries[*i].level >= level {
+		entry := entries[*i]
+		if entry.level > level {
+			return nil, fmt.Errorf("bookmark %q: level %d follows level %d; levels can only go one deeper at a time", entry.title, entry.level, level-1)
+		}
+		*i++
+
+		kids, err := nestBookmarks(entries, i, level+1)
+		if err != nil {
+			return nil, err
+		}
+		bms = append(bms, pdfcpu.Bookmark{Title: entry.title, PageFrom: entry.page, Kids: kids})
+	}
+	return bms, nil
+}
+
+// pageBookmarks returns the bookmarks that point to a page of the document.
+// One that does not is replaced by its children.
+func pageBookmarks(bms []pdfcpu.Bookmark, pageCount int) []pdfcpu.Bookmark {
+	var result []pdfcpu.Bookmark
+	for _, bm := range bms {
+		kids := pageBookmarks(bm.Kids, pageCount)
+		if bm.PageFrom < 1 || bm.PageFrom > pageCount {
+			result = append(result, kids...)
+			continue
+		}
+
+		bm.PageThru = 0
+		bm.Parent = nil
+		bm.Kids = kids
+		result = append(result, bm)
+	}
+	return result
+}
+
+// sortBookmarks puts bookmarks and their children in page order. A parent
+// moves up to its first child if that comes earlier.
+func sortBookmarks(bms []pdfcpu.Bookmark) {
+	for i := range bms {
+		sortBookmarks(bms[i].Kids)
+	}
+	orderBookmarks(bms)
+}
+
+// readBookmarksContext reads and validates a PDF file for a bookmark command
+func readBookmarksContext(inputPath string) (*model.Context, error) {
+	conf := model.NewDefaultConfiguration()
+	conf.Cmd = model.ADDBOOKMARKS
+
+	ctx, err := readValidateAndOptimizeFile(inputPath, conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	return ctx, nil
+}
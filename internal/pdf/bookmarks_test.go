@@ -0,0 +1,54 @@
+package pdf
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeBrokenOutlinePDF writes a two-page PDF whose outline has a bookmark
+// with a broken destination above one that points to page 2, and returns its path
+func writeBrokenOutlinePDF(t *testing.T) string {
+	t.Helper()
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R /Outlines 5 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		"<< /Type /Outlines /First 6 0 R /Last 7 0 R /Count 3 >>",
+		"<< /Title (Cover) /Parent 5 0 R /Next 7 0 R /Dest [3 0 R /Fit] >>",
+		"<< /Title (Broken) /Parent 5 0 R /Prev 6 0 R /First 8 0 R /Last 8 0 R /Count 1 /Dest (missing) >>",
+		"<< /Title (Chapter) /Parent 7 0 R /Dest [4 0 R /Fit] >>",
+	}
+	return writeTestPDF(t, objects, "")
+}
+
+func TestExportBookmarksSkipsBrokenDestinations(t *testing.T) {
+	want := []Bookmark{
+		{Title: "Cover", Page: 1, Level: 1},
+		{Title: "Chapter", Page: 2, Level: 1},
+	}
+
+	in := writeBrokenOutlinePDF(t)
+	got, err := ExportBookmarks(in)
+	if err != nil {
+		t.Fatalf("ExportBookmarks error: %v", err)
+	}
+	if !slices.EqualFunc(got, want, equalBookmark) {
+		t.Fatalf("bookmarks = %+v, want %+v", got, want)
+	}
+
+	// The export can be imported again as it is
+	out := filepath.Join(t.TempDir(), "out.pdf")
+	if err := ImportBookmarks(in, out, got, true); err != nil {
+		t.Fatalf("ImportBookmarks error: %v", err)
+	}
+	again, err := ExportBookmarks(out)
+	if err != nil {
+		t.Fatalf("ExportBookmarks error: %v", err)
+	}
+	if !slices.EqualFunc(again, want, equalBookmark) {
+		t.Errorf("bookmarks after import = %+v, want %+v", again, want)
+	}
+}
@@ -1,9 +1,20 @@
 package pdf
 
 import (
+	"bytes"
 	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png" // register PNG decoder for rendered image streams
+	"os"
+	"reflect"
+	"regexp"
 
 	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+	"golang.org/x/image/draw"
 )
 
 type CompressionLevel string
@@ -14,14 +25,492 @@ const (
 	CompressionHigh   CompressionLevel = "high"
 )
 
-// CompressPDF reduces the size of a PDF file
-func CompressPDF(inputPath, outputPath string, level CompressionLevel) error {
-	// Use default configuration for optimization
-	// pdfcpu's OptimizeFile will compress the PDF by removing unnecessary elements
-	// and optimizing internal structures
-	if err := api.OptimizeFile(inputPath, outputPath, nil); err != nil {
-		return fmt.Errorf("failed to compress PDF: %w", err)
+// CompressionStep reports the outcome of a single compression pass
+type CompressionStep struct {
+	Name       string `json:"name"`
+	BytesSaved int64  `json:"bytesSaved"`
+}
+
+// compressionPass is one step of a compression strategy.
+// apply may be nil for passes that only rely on pdfcpu's optimizer.
+// discard, if set, forgets what apply recorded when its output is not kept.
+type compressionPass struct {
+	name    string
+	apply   func(ctx *model.Context) error
+	discard func()
+}
+
+const (
+	mediumJPEGQuality = 75
+	highJPEGQuality   = 60
+	highTargetDPI     = 150
+)
+
+// ParseCompressionLevel returns the compression level named s
+func ParseCompressionLevel(s string) (CompressionLevel, error) {
+	switch level := CompressionLevel(s); level {
+	case CompressionLow, CompressionMedium, CompressionHigh:
+		return level, nil
+	default:
+		return "", fmt.Errorf("unknown compression level: %s", s)
+	}
+}
+
+// compressionPasses returns the ordered passes that make up a compression level.
+// Each level builds on the one below it.
+func compressionPasses(level CompressionLevel) ([]compressionPass, error) {
+	dedupe := compressionPass{name: "Deduplicate resources"}
+
+	switch level {
+	case CompressionLow:
+		return []compressionPass{dedupe}, nil
+	case CompressionMedium:
+		return []compressionPass{
+			dedupe,
+			{name: "Recompress images", apply: func(ctx *model.Context) error {
+				return recompressImages(ctx, mediumJPEGQuality, 0, map[int]bool{})
+			}},
+		}, nil
+	case CompressionHigh:
+		// Images that were downsampled are already JPEGs at the target
+		// quality, so recompressing them again would only lose detail
+		downsampled := map[int]bool{}
+		return []compressionPass{
+			dedupe,
+			{
+				name: "Downsample images",
+				apply: func(ctx *model.Context) error {
+					return recompressImages(ctx, highJPEGQuality, highTargetDPI, downsampled)
+				},
+				discard: func() { clear(downsampled) },
+			},
+			{name: "Recompress images", apply: func(ctx *model.Context) error {
+				return recompressImages(ctx, highJPEGQuality, 0, downsampled)
+			}},
+			{name: "Drop unused fonts", apply: dropUnusedFonts},
+			{name: "Strip metadata", apply: stripMetadata},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown compression level: %s", level)
+	}
+}
+
+// CompressPDF reduces the size of a PDF file and reports the bytes saved by each step
+func CompressPDF(inputPath, outputPath string, level CompressionLevel) ([]CompressionStep, error) {
+	passes, err := compressionPasses(level)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	// Run each pass on the output of the previous one so the savings
+	// can be attributed to the step that produced them
+	steps := make([]CompressionStep, 0, len(passes))
+	for _, pass := range passes {
+		out, err := runCompressionPass(data, pass)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compress PDF (%s): %w", pass.name, err)
+		}
+
+		saved := int64(len(data) - len(out))
+		if saved < 0 {
+			// Keep the smaller result if a pass did not pay off
+			saved = 0
+			if pass.discard != nil {
+				pass.discard()
+			}
+		} else {
+			data = out
+		}
+		steps = append(steps, CompressionStep{Name: pass.name, BytesSaved: saved})
+	}
+
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write compressed PDF: %w", err)
+	}
+
+	return steps, nil
+}
+
+// runCompressionPass reads and optimizes data, applies the pass and returns the rewritten PDF
+func runCompressionPass(data []byte, pass compressionPass) ([]byte, error) {
+	conf := model.NewDefaultConfiguration()
+	conf.Cmd = model.OPTIMIZE
+
+	ctx, err := api.ReadValidateAndOptimize(bytes.NewReader(data), conf)
+	if err != nil {
+		return nil, err
+	}
+
+	if pass.apply != nil {
+		if err := pass.apply(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := api.WriteContext(ctx, &buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// recompressImages re-encodes embedded raster images as JPEG.
+// When maxDPI is set only images exceeding that resolution are touched, and
+// they are scaled down to it. The effective resolution is estimated from the
+// largest page the image appears on, assuming it spans the full page.
+// Images whose object number is in done are skipped, and those re-encoded are
+// added to it. Passes keep object numbers, so done carries over between them.
+func recompressImages(ctx *model.Context, quality, maxDPI int, done map[int]bool) error {
+	dims, err := ctx.PageDims()
+	if err != nil {
+		return err
+	}
+
+	for objNr, imgObj := range ctx.Optimize.ImageObjects {
+		sd := imgObj.ImageDict
+		if done[objNr] || !recompressible(sd) {
+			continue
+		}
+
+		width, height := 0, 0
+		if w := sd.IntEntry("Width"); w != nil {
+			width = *w
+		}
+		if h := sd.IntEntry("Height"); h != nil {
+			height = *h
+		}
+		if width == 0 || height == 0 {
+			continue
+		}
+
+		newWidth, newHeight := width, height
+		if maxDPI > 0 {
+			maxW, maxH := 0.0, 0.0
+			// ResourceNames is keyed by zero-based page index
+			for pageIdx := range imgObj.ResourceNames {
+				if pageIdx < 0 || pageIdx >= len(dims) {
+					continue
+				}
+				maxW = max(maxW, dims[pageIdx].Width/72*float64(maxDPI))
+				maxH = max(maxH, dims[pageIdx].Height/72*float64(maxDPI))
+			}
+			if maxW == 0 || (float64(width) <= maxW && float64(height) <= maxH) {
+				continue
+			}
+			scale := min(maxW/float64(width), maxH/float64(height))
+			newWidth = max(1, int(float64(width)*scale))
+			newHeight = max(1, int(float64(height)*scale))
+		}
+
+		img, err := pdfcpu.ExtractImage(ctx, sd, false, "", objNr, false)
+		if err != nil || img == nil {
+			// Leave images we cannot decode untouched
+			continue
+		}
+		if img.FileType != "png" && img.FileType != "jpg" {
+			continue
+		}
+
+		decoded, _, err := image.Decode(img)
+		if err != nil {
+			continue
+		}
+
+		gray := img.Comp == 1
+		decoded = scaleImage(decoded, newWidth, newHeight, gray)
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, decoded, &jpeg.Options{Quality: quality}); err != nil {
+			return err
+		}
+
+		// Only swap the stream if it actually got smaller
+		if sd.StreamLength != nil && int64(buf.Len()) >= *sd.StreamLength {
+			continue
+		}
+
+		cs := model.DeviceRGBCS
+		if gray {
+			cs = model.DeviceGrayCS
+		}
+
+		newSD, err := model.CreateDCTImageStreamDict(ctx.XRefTable, buf.Bytes(), newWidth, newHeight, 8, cs)
+		if err != nil {
+			return err
+		}
+		if smask, ok := sd.Find("SMask"); ok {
+			newSD.Insert("SMask", smask)
+		}
+
+		entry, ok := ctx.FindTableEntryLight(objNr)
+		if !ok {
+			continue
+		}
+		entry.Object = *newSD
+		imgObj.ImageDict = newSD
+		done[objNr] = true
 	}
 
 	return nil
 }
+
+// recompressible reports whether an image stream can safely be re-encoded as JPEG
+func recompressible(sd *types.StreamDict) bool {
+	if sd == nil {
+		return false
+	}
+	if im := sd.BooleanEntry("ImageMask"); im != nil && *im {
+		return false
+	}
+	if bpc := sd.IntEntry("BitsPerComponent"); bpc == nil || *bpc != 8 {
+		return false
+	}
+	// Color key masks and decode arrays do not survive lossy recompression
+	if _, ok := sd.Find("Mask"); ok {
+		return false
+	}
+	if _, ok := sd.Find("Decode"); ok {
+		return false
+	}
+	for _, f := range sd.FilterPipeline {
+		if f.Name == "JPXDecode" || f.Name == "JBIG2Decode" {
+			return false
+		}
+	}
+	return true
+}
+
+// scaleImage converts img to an RGB or grayscale raster of the given size
+func scaleImage(img image.Image, width, height int, gray bool) image.Image {
+	rect := image.Rect(0, 0, width, height)
+
+	var dst draw.Image
+	if gray {
+		dst = image.NewGray(rect)
+	} else {
+		dst = image.NewRGBA(rect)
+	}
+
+	if img.Bounds().Dx() == width && img.Bounds().Dy() == height {
+		draw.Draw(dst, rect, img, img.Bounds().Min, draw.Src)
+	} else {
+		draw.CatmullRom.Scale(dst, rect, img, img.Bounds(), draw.Src, nil)
+	}
+
+	return dst
+}
+
+// fontSelector matches the "/F1 12 Tf" operator that selects a font in a content stream
+var fontSelector = regexp.MustCompile(`/([^\s/\[\]()<>{}%]+)\s+[-+]?[\d.]+\s+Tf`)
+
+// fontUsage records which fonts of a font resource dict are selected
+type fontUsage struct {
+	fonts types.Dict
+	used  map[string]bool
+	all   bool // Some content using the dict could not be read, so keep every font
+}
+
+// fontScan collects font usage from the content streams of a document
+type fontScan struct {
+	ctx     *model.Context
+	usage   map[uintptr]*fontUsage
+	visited map[fontScanVisit]bool
+}
+
+// fontScanVisit identifies a form scanned with a given set of resources,
+// since forms without their own resources use those of whoever draws them
+type fontScanVisit struct {
+	objNr     int
+	resources uintptr
+}
+
+// dropUnusedFonts removes font resources that no content stream selects.
+// Pages, the form XObjects and tiling patterns they draw and the appearance
+// streams of their annotations are all scanned. Font dicts may be shared, so
+// usage is collected per dict before anything is removed.
+func dropUnusedFonts(ctx *model.Context) error {
+	s := &fontScan{ctx: ctx, usage: map[uintptr]*fontUsage{}, visited: map[fontScanVisit]bool{}}
+
+	for pageNr := 1; pageNr <= ctx.PageCount; pageNr++ {
+		d, _, inhPAttrs, err := ctx.PageDict(pageNr, false)
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			continue
+		}
+
+		var resources types.Dict
+		if obj, found := d.Find("Resources"); found {
+			if resources, err = ctx.DereferenceDict(obj); err != nil {
+				return err
+			}
+		} else if inhPAttrs != nil {
+			resources = inhPAttrs.Resources
+		}
+		if resources != nil {
+			content, err := ctx.PageContent(d, pageNr)
+			if err != nil && err != model.ErrNoContent {
+				return err
+			}
+			if err := s.scan(content, resources, 0); err != nil {
+				return err
+			}
+		}
+
+		annots, err := ctx.DereferenceArray(d["Annots"])
+		if err != nil {
+			continue
+		}
+		for _, o := range annots {
+			annot, err := ctx.DereferenceDict(o)
+			if err != nil || annot == nil {
+				continue
+			}
+			ap, err := ctx.DereferenceDict(annot["AP"])
+			if err != nil || ap == nil {
+				continue
+			}
+			// Each appearance is a form, or a dict of forms keyed by state
+			for _, key := range []string{"N", "R", "D"} {
+				switch v := dereferenceObject(ctx, ap[key]).(type) {
+				case types.StreamDict:
+					if err := s.form(ap[key], nil, 0); err != nil {
+						return err
+					}
+				case types.Dict:
+					for _, state := range v {
+						if err := s.form(state, nil, 0); err != nil {
+							return err
+						}
+					}
+				}
+			}
+		}
+	}
+
+	for _, u := range s.usage {
+		if u.all {
+			continue
+		}
+		for name := range u.fonts {
+			if !u.used[name] {
+				delete(u.fonts, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// fontUsage returns the usage of the font dict of resources, or nil if it has no fonts
+func (s *fontScan) fontUsage(resources types.Dict) (*fontUsage, error) {
+	obj, found := resources.Find("Font")
+	if !found {
+		return nil, nil
+	}
+	fonts, err := s.ctx.DereferenceDict(obj)
+	if err != nil {
+		return nil, err
+	}
+	if len(fonts) == 0 {
+		return nil, nil
+	}
+
+	key := reflect.ValueOf(fonts).Pointer()
+	u, ok := s.usage[key]
+	if !ok {
+		u = &fontUsage{fonts: fonts, used: map[string]bool{}}
+		s.usage[key] = u
+	}
+	return u, nil
+}
+
+// scan records the fonts content selects from resources, then scans the
+// forms and tiling patterns the resources provide
+func (s *fontScan) scan(content []byte, resources types.Dict, depth int) error {
+	u, err := s.fontUsage(resources)
+	if err != nil {
+		return err
+	}
+	if u != nil {
+		for _, m := range fontSelector.FindAllSubmatch(content, -1) {
+			name, err := types.DecodeName(string(m[1]))
+			if err != nil {
+				// Be conservative with names we cannot decode
+				name = string(m[1])
+			}
+			u.used[name] = true
+		}
+	}
+
+	for _, key := range []string{"XObject", "Pattern"} {
+		d, err := s.ctx.DereferenceDict(resources[key])
+		if err != nil || d == nil {
+			continue
+		}
+		for _, o := range d {
+			if err := s.form(o, resources, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// form scans a form XObject or tiling pattern. Those without resources of
+// their own use inherited, the resources of whoever draws them.
+func (s *fontScan) form(o types.Object, inherited types.Dict, depth int) error {
+	sd, _, err := s.ctx.DereferenceStreamDict(o)
+	if err != nil || sd == nil {
+		return nil
+	}
+	subtype := sd.Subtype()
+	patternType := sd.IntEntry("PatternType")
+	if (subtype == nil || *subtype != "Form") && (patternType == nil || *patternType != 1) {
+		return nil
+	}
+
+	resources := inherited
+	if d, err := s.ctx.DereferenceDict(sd.Dict["Resources"]); err == nil && d != nil {
+		resources = d
+	}
+	if resources == nil {
+		return nil
+	}
+
+	if ref, ok := o.(types.IndirectRef); ok {
+		visit := fontScanVisit{objNr: ref.ObjectNumber.Value(), resources: reflect.ValueOf(resources).Pointer()}
+		if s.visited[visit] {
+			return nil
+		}
+		s.visited[visit] = true
+	}
+
+	// Keep every font the form could select if its content cannot be checked
+	if depth > maxFormDepth || sd.Decode() != nil {
+		u, err := s.fontUsage(resources)
+		if err != nil {
+			return err
+		}
+		if u != nil {
+			u.all = true
+		}
+		return nil
+	}
+
+	return s.scan(sd.Content, resources, depth)
+}
+
+// stripMetadata removes the document information dictionary and XMP metadata
+func stripMetadata(ctx *model.Context) error {
+	ctx.Info = nil
+	return ctx.DeleteDictEntry(ctx.RootDict, "Metadata")
+}
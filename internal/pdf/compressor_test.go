@@ -0,0 +1,213 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// writeImagePDF writes a PDF with a one inch page filled by an uncompressed
+// RGB image of the given size, and returns its path
+func writeImagePDF(t *testing.T, width, height int) string {
+	t.Helper()
+
+	pixels := make([]byte, 0, width*height*3)
+	for y := range height {
+		for x := range width {
+			pixels = append(pixels, byte(x*255/width), byte(y*255/height), byte((x+y)%256))
+		}
+	}
+
+	content := "q 72 0 0 72 0 0 cm /Im1 Do Q"
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 72 72] /Resources << /XObject << /Im1 4 0 R >> >> /Contents 5 0 R >>",
+		fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Length %d >>\nstream\n%s\nendstream",
+			width, height, len(pixels), pixels),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+	}
+	return writeTestPDF(t, objects, "")
+}
+
+// pageImage returns the image drawn as /Im1 on the first page of a PDF
+func pageImage(t *testing.T, data []byte) *types.StreamDict {
+	t.Helper()
+
+	ctx, err := api.ReadValidateAndOptimize(bytes.NewReader(data), model.NewDefaultConfiguration())
+	if err != nil {
+		t.Fatalf("failed to read PDF: %v", err)
+	}
+	pageDict, _, _, err := ctx.PageDict(1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resources, _ := ctx.DereferenceDict(pageDict["Resources"])
+	xobjects, _ := ctx.DereferenceDict(resources["XObject"])
+	sd, _, err := ctx.DereferenceStreamDict(xobjects["Im1"])
+	if err != nil || sd == nil {
+		t.Fatalf("image not found: %v", err)
+	}
+	return sd
+}
+
+func TestCompressPDFLevels(t *testing.T) {
+	tests := []struct {
+		level      CompressionLevel
+		steps      []string
+		wantFilter string // Filter of the image afterwards
+		wantWidth  int
+	}{
+		{CompressionLow, []string{"Deduplicate resources"}, "", 300},
+		{CompressionMedium, []string{"Deduplicate resources", "Recompress images"}, "DCTDecode", 300},
+		{CompressionHigh, []string{"Deduplicate resources", "Downsample images", "Recompress images", "Drop unused fonts", "Strip metadata"}, "DCTDecode", 150},
+	}
+
+	in := writeImagePDF(t, 300, 300)
+	for _, tt := range tests {
+		out := filepath.Join(t.TempDir(), "out.pdf")
+		steps, err := CompressPDF(in, out, tt.level)
+		if err != nil {
+			t.Errorf("%s: CompressPDF error: %v", tt.level, err)
+			continue
+		}
+
+		var names []string
+		for _, step := range steps {
+			names = append(names, step.Name)
+		}
+		if !slices.Equal(names, tt.steps) {
+			t.Errorf("%s: steps = %v, want %v", tt.level, names, tt.steps)
+		}
+
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sd := pageImage(t, data)
+		filter := ""
+		if f := sd.NameEntry("Filter"); f != nil {
+			filter = *f
+		}
+		if filter != tt.wantFilter {
+			t.Errorf("%s: image filter = %q, want %q", tt.level, filter, tt.wantFilter)
+		}
+		if w := sd.IntEntry("Width"); w == nil || *w != tt.wantWidth {
+			t.Errorf("%s: image width = %v, want %d", tt.level, w, tt.wantWidth)
+		}
+	}
+}
+
+func TestCompressPDFUnknownLevel(t *testing.T) {
+	if _, err := ParseCompressionLevel("extreme"); err == nil {
+		t.Errorf("ParseCompressionLevel accepted an unknown level")
+	}
+	for _, level := range []string{"low", "medium", "high"} {
+		if _, err := ParseCompressionLevel(level); err != nil {
+			t.Errorf("ParseCompressionLevel(%q) error: %v", level, err)
+		}
+	}
+
+	out := filepath.Join(t.TempDir(), "out.pdf")
+	if _, err := CompressPDF(writeImagePDF(t, 8, 8), out, "extreme"); err == nil {
+		t.Errorf("CompressPDF accepted an unknown level")
+	}
+}
+
+func TestCompressHighEncodesImagesOnce(t *testing.T) {
+	passes, err := compressionPasses(CompressionHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(writeImagePDF(t, 300, 300))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	images := map[string][]byte{}
+	for _, pass := range passes {
+		if data, err = runCompressionPass(data, pass); err != nil {
+			t.Fatalf("%s: %v", pass.name, err)
+		}
+		images[pass.name] = pageImage(t, data).Raw
+	}
+
+	if !bytes.Equal(images["Downsample images"], images["Recompress images"]) {
+		t.Errorf("Recompress images re-encoded the downsampled image")
+	}
+}
+
+func TestDropUnusedFonts(t *testing.T) {
+	content := "BT /F1 12 Tf (a) Tj ET /Fm1 Do"
+	form := "/F2 12 Tf"
+	formOwn := "/FA 12 Tf"
+	appearance := "/FX 12 Tf"
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Annots [7 0 R]" +
+			" /Resources << /Font << /F1 10 0 R /F2 10 0 R /F3 10 0 R >> /XObject << /Fm1 5 0 R /Fm2 6 0 R >> >> /Contents 4 0 R >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		// A form without resources uses those of the page
+		fmt.Sprintf("<< /Type /XObject /Subtype /Form /BBox [0 0 10 10] /Length %d >>\nstream\n%s\nendstream", len(form), form),
+		fmt.Sprintf("<< /Type /XObject /Subtype /Form /BBox [0 0 10 10] /Resources << /Font << /FA 10 0 R /FB 10 0 R >> >> /Length %d >>\nstream\n%s\nendstream",
+			len(formOwn), formOwn),
+		"<< /Type /Annot /Subtype /FreeText /Rect [0 0 10 10] /AP << /N 8 0 R >> >>",
+		fmt.Sprintf("<< /Type /XObject /Subtype /Form /BBox [0 0 10 10] /Resources << /Font << /FX 10 0 R /FY 10 0 R >> >> /Length %d >>\nstream\n%s\nendstream",
+			len(appearance), appearance),
+		"<< >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+
+	ctx, err := api.ReadContextFile(writeTestPDF(t, objects, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dropUnusedFonts(ctx); err != nil {
+		t.Fatalf("dropUnusedFonts error: %v", err)
+	}
+
+	fontNames := func(o types.Object) []string {
+		sd, _, err := ctx.DereferenceStreamDict(o)
+		d := types.Dict(nil)
+		if err == nil && sd != nil {
+			d, _ = ctx.DereferenceDict(sd.Dict["Resources"])
+		} else {
+			d, _ = ctx.DereferenceDict(o)
+		}
+		fonts, _ := ctx.DereferenceDict(d["Font"])
+		var names []string
+		for name := range fonts {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	pageDict, _, _, err := ctx.PageDict(1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		o    types.Object
+		want []string
+	}{
+		{"page", pageDict["Resources"], []string{"F1", "F2"}},
+		{"form", *types.NewIndirectRef(6, 0), []string{"FA"}},
+		{"appearance", *types.NewIndirectRef(8, 0), []string{"FX"}},
+	}
+	for _, tt := range tests {
+		if got := fontNames(tt.o); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: fonts = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
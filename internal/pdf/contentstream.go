@@ -0,0 +1,374 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// contentOp is an operator of a content stream together with its operands.
+// Operands are float64 numbers, []byte strings, contentName names, []any
+// arrays, bool, or nil for null and dictionaries, whose contents are not needed.
+type contentOp struct {
+	name     string
+	operands []any
+}
+
+// contentName is a name operand without its leading slash
+type contentName string
+
+// parseContent splits a content stream or CMap into its operators.
+// The data of inline images is skipped.
+func parseContent(data []byte) ([]contentOp, error) {
+	l := contentLexer{data: data}
+
+	var (
+		ops      []contentOp
+		operands []any
+	)
+	for {
+		tok, isOp, err := l.next()
+		if err == io.EOF {
+			return ops, nil
+		}
+		if err != nil {
+			return ops, err
+		}
+		if !isOp {
+			operands = append(operands, tok)
+			continue
+		}
+
+		name := tok.(string)
+		if name == "BI" {
+			if err := l.skipInlineImage(); err != nil {
+				return ops, err
+			}
+			operands = nil
+			continue
+		}
+		ops = append(ops, contentOp{name: name, operands: operands})
+		operands = nil
+	}
+}
+
+// maxArrayDepth limits how deeply arrays may be nested. Content streams never
+// nest them, and each level of nesting costs a stack frame.
+const maxArrayDepth = 32
+
+// contentLexer reads the tokens of a content stream
+type contentLexer struct {
+	data  []byte
+	pos   int
+	depth int // Arrays being read
+}
+
+// next returns the next operand, or the next operator as a string with isOp set.
+// At the end of the data it returns io.EOF.
+func (l *contentLexer) next() (tok any, isOp bool, err error) {
+	for {
+		l.skipSpace()
+		if l.pos >= len(l.data) {
+			return nil, false, io.EOF
+		}
+
+		c := l.data[l.pos]
+		switch {
+		case c == '(':
+			s, err := l.literalString()
+			return s, false, err
+		case c == '<' && l.peek(1) == '<':
+			l.pos += 2
+			return nil, false, l.skipDict()
+		case c == '<':
+			s, err := l.hexString()
+			return s, false, err
+		case c == '[':
+			l.pos++
+			a, err := l.array()
+			return a, false, err
+		case c == '/':
+			l.pos++
+			return contentName(l.name()), false, nil
+		case c == ']' || c == ')' || c == '>' || c == '{' || c == '}':
+			// Stray delimiters are skipped, like PostScript procedure braces in CMaps
+			l.pos++
+			continue
+		case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+			word := l.word()
+			n, err := strconv.ParseFloat(word, 64)
+			if err != nil {
+				// Malformed numbers like "--1" or "1.2.3" are read as zero, as viewers do
+				return float64(0), false, nil
+			}
+			return n, false, nil
+		}
+
+		word := l.word()
+		if word == "" {
+			l.pos++
+			continue
+		}
+		switch word {
+		case "true":
+			return true, false, nil
+		case "false":
+			return false, false, nil
+		case "null":
+			return nil, false, nil
+		}
+		return word, true, nil
+	}
+}
+
+// peek returns the byte n positions ahead, or 0 past the end
+func (l *contentLexer) peek(n int) byte {
+	if l.pos+n < len(l.data) {
+		return l.data[l.pos+n]
+	}
+	return 0
+}
+
+// skipSpace skips white space and comments
+func (l *contentLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		if !isContentSpace(c) {
+			return
+		}
+		l.pos++
+	}
+}
+
+// word reads a run of regular characters
+func (l *contentLexer) word() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isContentSpace(l.data[l.pos]) && !isContentDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return string(l.data[start:l.pos])
+}
+
+// name reads a name after its slash and resolves #xx escapes
+func (l *contentLexer) name() string {
+	word := l.word()
+	if !bytes.Contains([]byte(word), []byte("#")) {
+		return word
+	}
+
+	var b []byte
+	for i := 0; i < len(word); i++ {
+		if word[i] == '#' && i+2 < len(word) {
+			if v, err := strconv.ParseUint(word[i+1:i+3], 16, 8); err == nil {
+				b = append(b, byte(v))
+				i += 2
+				continue
+			}
+		}
+		b = append(b, word[i])
+	}
+	return string(b)
+}
+
+// literalString reads a string in parentheses and resolves its escapes
+func (l *contentLexer) literalString() ([]byte, error) {
+	l.pos++ // (
+	var b []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return b, nil
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return b, nil
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				b = append(b, '\n')
+			case 'r':
+				b = append(b, '\r')
+			case 't':
+				b = append(b, '\t')
+			case 'b':
+				b = append(b, '\b')
+			case 'f':
+				b = append(b, '\f')
+			case '\r':
+				// A backslash at the end of a line continues the string
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					b = append(b, byte(v))
+				} else {
+					b = append(b, e)
+				}
+			}
+			continue
+		}
+		b = append(b, c)
+	}
+	return nil, fmt.Errorf("unterminated string")
+}
+
+// hexString reads a string in angle brackets
+func (l *contentLexer) hexString() ([]byte, error) {
+	l.pos++ // <
+	var digits []byte
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		if c == '>' {
+			// An odd final digit is followed by an implied 0
+			if len(digits)%2 == 1 {
+				digits = append(digits, '0')
+			}
+			b := make([]byte, len(digits)/2)
+			for i := range b {
+				v, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+				b[i] = byte(v)
+			}
+			return b, nil
+		}
+		if isHexDigit(c) {
+			digits = append(digits, c)
+		}
+	}
+	return nil, fmt.Errorf("unterminated hex string")
+}
+
+// array reads the elements of an array after its opening bracket
+func (l *contentLexer) array() ([]any, error) {
+	if l.depth >= maxArrayDepth {
+		return nil, fmt.Errorf("arrays nested too deeply")
+	}
+	l.depth++
+	defer func() { l.depth-- }()
+
+	a := []any{}
+	for {
+		l.skipSpace()
+		if l.pos >= len(l.data) {
+			return nil, fmt.Errorf("unterminated array")
+		}
+		if l.data[l.pos] == ']' {
+			l.pos++
+			return a, nil
+		}
+
+		tok, isOp, err := l.next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("unterminated array")
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Operators are not allowed in arrays; keep going as viewers do
+		if !isOp {
+			a = append(a, tok)
+		}
+	}
+}
+
+// skipDict skips a dictionary after its opening brackets
+func (l *contentLexer) skipDict() error {
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case c == '(':
+			if _, err := l.literalString(); err != nil {
+				return err
+			}
+			continue
+		case c == '<' && l.peek(1) == '<':
+			depth++
+			l.pos += 2
+			continue
+		case c == '>' && l.peek(1) == '>':
+			depth--
+			l.pos += 2
+			if depth == 0 {
+				return nil
+			}
+			continue
+		case c == '<':
+			if _, err := l.hexString(); err != nil {
+				return err
+			}
+			continue
+		}
+		l.pos++
+	}
+	return fmt.Errorf("unterminated dictionary")
+}
+
+// skipInlineImage skips an inline image after BI: its parameters, the ID
+// operator, the image data and the EI operator that ends it
+func (l *contentLexer) skipInlineImage() error {
+	for {
+		tok, isOp, err := l.next()
+		if err == io.EOF {
+			return fmt.Errorf("unterminated inline image")
+		}
+		if err != nil {
+			return err
+		}
+		if isOp && tok.(string) == "ID" {
+			break
+		}
+	}
+
+	// The data starts after a single white space character and ends at an EI
+	// that stands on its own
+	l.pos++
+	for l.pos+1 < len(l.data) {
+		if l.data[l.pos] == 'E' && l.data[l.pos+1] == 'I' &&
+			l.pos > 0 && isContentSpace(l.data[l.pos-1]) &&
+			(l.pos+2 == len(l.data) || isContentSpace(l.data[l.pos+2]) || isContentDelimiter(l.data[l.pos+2])) {
+			l.pos += 2
+			return nil
+		}
+		l.pos++
+	}
+	return fmt.Errorf("unterminated inline image")
+}
+
+// isContentSpace reports whether c is PDF white space
+func isContentSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+// isContentDelimiter reports whether c ends a number, name or operator
+func isContentDelimiter(c byte) bool {
+	return bytes.IndexByte([]byte("()<>[]{}/%"), c) >= 0
+}
+
+// isHexDigit reports whether c is a hexadecimal digit
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
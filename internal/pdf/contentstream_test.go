@@ -0,0 +1,102 @@
+package pdf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseContent(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []contentOp
+	}{
+		{"text object", "BT /F1 12 Tf (Hi) Tj ET", []contentOp{
+			{name: "BT"},
+			{name: "Tf", operands: []any{contentName("F1"), 12.0}},
+			{name: "Tj", operands: []any{[]byte("Hi")}},
+			{name: "ET"},
+		}},
+		{"hex string", "<48 69> Tj", []contentOp{{name: "Tj", operands: []any{[]byte("Hi")}}}},
+		{"odd hex digits", "<486> Tj", []contentOp{{name: "Tj", operands: []any{[]byte("H`")}}}},
+		{"escapes", `(a\(b\)\n\101\\) Tj`, []contentOp{{name: "Tj", operands: []any{[]byte("a(b)\nA\\")}}}},
+		{"nested parentheses", "(a(b)c) Tj", []contentOp{{name: "Tj", operands: []any{[]byte("a(b)c")}}}},
+		{"array", "[(A) -120 (B)] TJ", []contentOp{{name: "TJ", operands: []any{[]any{[]byte("A"), -120.0, []byte("B")}}}}},
+		{"nested array", "[[1 2] 3] x", []contentOp{{name: "x", operands: []any{[]any{[]any{1.0, 2.0}, 3.0}}}}},
+		{"operator in array", "[1 w 2] x", []contentOp{{name: "x", operands: []any{[]any{1.0, 2.0}}}}},
+		{"dictionary", "/P <</MCID 0 /Sub <</A (>>)>> >> BDC EMC", []contentOp{
+			{name: "BDC", operands: []any{contentName("P"), nil}},
+			{name: "EMC"},
+		}},
+		{"stray delimiters", "] } ) > 1 x", []contentOp{{name: "x", operands: []any{1.0}}}},
+		{"malformed number", "--1 x", []contentOp{{name: "x", operands: []any{0.0}}}},
+		{"name escape", "/A#20B x", []contentOp{{name: "x", operands: []any{contentName("A B")}}}},
+		{"keywords", "true false null x", []contentOp{{name: "x", operands: []any{true, false, nil}}}},
+		{"comment", "% 1 w\n2 w", []contentOp{{name: "w", operands: []any{2.0}}}},
+		{"inline image", "q BI /W 2 /H 1 ID \x00EI\xff EI Q", []contentOp{{name: "q"}, {name: "Q"}}},
+	}
+
+	for _, tt := range tests {
+		got, err := parseContent([]byte(tt.data))
+		if err != nil {
+			t.Errorf("%s: parseContent(%q) error: %v", tt.name, tt.data, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: parseContent(%q) = %v, want %v", tt.name, tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestParseContentErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		keep int // Operators read before the error
+	}{
+		{"unterminated string", "1 w (abc", 1},
+		{"unterminated hex string", "1 w <414", 1},
+		{"unterminated array", "1 w [1 2", 1},
+		{"unterminated dictionary", "1 w <</A 1", 1},
+		{"unterminated inline image", "1 w BI /W 1 ID abc", 1},
+		{"deeply nested arrays", "1 w " + strings.Repeat("[", maxArrayDepth+1), 1},
+		// Flate makes a few kilobytes into megabytes of brackets, which must
+		// not exhaust the stack
+		{"millions of brackets", strings.Repeat("[", 4<<20), 0},
+	}
+
+	for _, tt := range tests {
+		ops, err := parseContent([]byte(tt.data))
+		if err == nil {
+			t.Errorf("%s: parseContent succeeded, want an error", tt.name)
+		}
+		if len(ops) != tt.keep {
+			t.Errorf("%s: parseContent kept %d operators, want %d", tt.name, len(ops), tt.keep)
+		}
+	}
+}
+
+func TestParseContentStrayDelimiters(t *testing.T) {
+	// Skipping stray delimiters must not recurse either
+	data := strings.Repeat("]", 4<<20) + " x"
+	ops, err := parseContent([]byte(data))
+	if err != nil {
+		t.Fatalf("parseContent error: %v", err)
+	}
+	if len(ops) != 1 || ops[0].name != "x" {
+		t.Errorf("parseContent = %v, want [x]", ops)
+	}
+}
+
+func FuzzParseContent(f *testing.F) {
+	f.Add([]byte("BT /F1 12 Tf 72 720 Td (Hello) Tj ET"))
+	f.Add([]byte("[(A) -120 <42>] TJ /P <</MCID 0>> BDC EMC"))
+	f.Add([]byte("q BI /W 1 /H 1 ID \x00 EI Q"))
+	f.Add([]byte("/CIDInit /ProcSet findresource begin 1 begincodespacerange <00> <FF> endcodespacerange"))
+	f.Add([]byte("[[[[ ]]]] } { ) > (\\"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		parseContent(data)
+	})
+}
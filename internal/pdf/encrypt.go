@@ -1,24 +1,151 @@
 package pdf
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
 	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
 )
 
+// EncryptOptions controls the passwords, permissions and algorithm of an encrypted PDF
+type EncryptOptions struct {
+	UserPassword  string      // Needed to open the document; empty lets anyone open it with the permissions below
+	OwnerPassword string      // Grants full access; empty makes the user password the owner password
+	Permissions   Permissions // What a user who opened the document without the owner password may do
+	Algorithm     string      // "AES" or "RC4"; empty for AES
+	KeyLength     int         // 128 or 256 for AES, 40 or 128 for RC4; 0 for the strongest
+	AllowWeak     bool        // Allow RC4 and permissions that the user password can lift
+}
+
 // AddPDFPassword adds password protection to a PDF file.
-// The output PDF will require a password to open.
-func AddPDFPassword(inputPath, outputPath, password string) error {
-	// Create configuration with the new password
-	conf := model.NewDefaultConfiguration()
-	conf.UserPW = password
-	conf.OwnerPW = password // Set both user and owner passwords
+// The output PDF will require the user password to open, if one is set.
+func AddPDFPassword(inputPath, outputPath string, opts EncryptOptions) error {
+	conf, err := encryptConfig(opts)
+	if err != nil {
+		return err
+	}
 
-	// Encrypt the PDF with the password
+	// Encrypt the PDF with the passwords
 	if err := api.EncryptFile(inputPath, outputPath, conf); err != nil {
 		return fmt.Errorf("failed to add password: %w", err)
 	}
 
 	return nil
 }
+
+// ChangePassword re-encrypts a PDF file with new passwords, permissions or algorithm.
+// ownerPassword must be the document's current owner password. An empty
+// opts.OwnerPassword keeps it. The document is only decrypted in memory, so no
+// unprotected copy is written to disk.
+func ChangePassword(inputPath, outputPath, ownerPassword string, opts EncryptOptions) error {
+	if opts.OwnerPassword == "" {
+		opts.OwnerPassword = ownerPassword
+	}
+
+	conf, err := encryptConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer f.Close()
+
+	// pdfcpu falls back to the user password when the owner password does not
+	// match, so pass one that cannot match to insist on the owner password
+	readConf := model.NewDefaultConfiguration()
+	readConf.OwnerPW = ownerPassword
+	readConf.UserPW = unmatchablePassword()
+
+	ctx, err := api.ReadContext(f, readConf)
+	if err != nil {
+		if errors.Is(err, pdfcpu.ErrWrongPassword) {
+			return fmt.Errorf("the current owner password is required to change passwords or permissions")
+		}
+		return fmt.Errorf("failed to read PDF: %w", err)
+	}
+	if ctx.Encrypt == nil {
+		return fmt.Errorf("PDF is not encrypted, add a password instead")
+	}
+
+	// Drop the old encryption and let the writer set up the new one
+	ctx.Encrypt = nil
+	ctx.E = nil
+	ctx.EncKey = nil
+	ctx.Cmd = model.ENCRYPT
+	ctx.UserPW = conf.UserPW
+	ctx.OwnerPW = conf.OwnerPW
+	ctx.EncryptUsingAES = conf.EncryptUsingAES
+	ctx.EncryptKeyLength = conf.EncryptKeyLength
+	ctx.Permissions = conf.Permissions
+
+	if err := api.WriteContextFile(ctx, outputPath); err != nil {
+		return fmt.Errorf("failed to write PDF: %w", err)
+	}
+
+	return nil
+}
+
+// unmatchablePassword returns a random password that no document is protected with
+func unmatchablePassword() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// encryptConfig validates opts and turns them into a pdfcpu configuration
+func encryptConfig(opts EncryptOptions) (*model.Configuration, error) {
+	if opts.UserPassword == "" && opts.OwnerPassword == "" {
+		return nil, fmt.Errorf("a user or owner password is required")
+	}
+
+	conf := model.NewDefaultConfiguration()
+	conf.UserPW = opts.UserPassword
+	conf.OwnerPW = opts.OwnerPassword
+	if conf.OwnerPW == "" {
+		conf.OwnerPW = opts.UserPassword
+	}
+	conf.Permissions = opts.Permissions.flags()
+
+	switch strings.ToUpper(opts.Algorithm) {
+	case "", "AES":
+		conf.EncryptUsingAES = true
+		conf.EncryptKeyLength = opts.KeyLength
+		if conf.EncryptKeyLength == 0 {
+			conf.EncryptKeyLength = 256
+		}
+		if conf.EncryptKeyLength != 128 && conf.EncryptKeyLength != 256 {
+			return nil, fmt.Errorf("AES key length must be 128 or 256 bits, got %d", conf.EncryptKeyLength)
+		}
+	case "RC4":
+		conf.EncryptUsingAES = false
+		conf.EncryptKeyLength = opts.KeyLength
+		if conf.EncryptKeyLength == 0 {
+			conf.EncryptKeyLength = 128
+		}
+		if conf.EncryptKeyLength != 40 && conf.EncryptKeyLength != 128 {
+			return nil, fmt.Errorf("RC4 key length must be 40 or 128 bits, got %d", conf.EncryptKeyLength)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported encryption algorithm: %s", opts.Algorithm)
+	}
+
+	if !opts.AllowWeak {
+		if !conf.EncryptUsingAES {
+			return nil, fmt.Errorf("RC4 encryption can be broken; use AES or allow weak encryption")
+		}
+		if opts.Permissions.Restricted() && conf.OwnerPW == conf.UserPW {
+			return nil, fmt.Errorf("permissions are not enforced when the owner password is empty or the same as the user password; set a different owner password or allow weak encryption")
+		}
+	}
+
+	return conf, nil
+}
@@ -0,0 +1,480 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/font"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// FlattenOptions selects what is turned into page content
+type FlattenOptions struct {
+	Forms       bool // Form fields, which also removes the interactive form
+	Annotations bool // Comments, highlights, stamps and other markup; links are kept
+}
+
+// FlattenResult counts what was flattened
+type FlattenResult struct {
+	Fields      int // Form field widgets drawn onto their pages
+	Annotations int // Annotations drawn onto their pages
+}
+
+// Annotation flags that keep an annotation from being shown
+const (
+	annotHidden = 1 << 1
+	annotNoView = 1 << 5
+)
+
+// Flatten writes a copy of a PDF file in which form fields and annotations are
+// drawn as ordinary page content, so their values can no longer be changed.
+// Each is drawn the way viewers show it, from its normal appearance stream.
+// Text fields and choice lists without one are drawn from their value; hidden
+// annotations and others without an appearance are removed.
+func Flatten(inputPath, outputPath string, opts FlattenOptions) (FlattenResult, error) {
+	if !opts.Forms && !opts.Annotations {
+		return FlattenResult{}, fmt.Errorf("nothing to flatten, choose forms, annotations or both")
+	}
+
+	ctx, err := api.ReadContextFile(inputPath)
+	if err != nil {
+		return FlattenResult{}, fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	var result FlattenResult
+	for page := 1; page <= ctx.PageCount; page++ {
+		if err := flattenPage(ctx, page, opts, &result); err != nil {
+			return FlattenResult{}, fmt.Errorf("failed to flatten page %d: %w", page, err)
+		}
+	}
+
+	if opts.Forms {
+		// Without widgets the form has nothing left to show; dropping it also
+		// drops XFA data and keeps viewers from offering to fill it in
+		delete(ctx.RootDict, "AcroForm")
+		ctx.Form = nil
+	}
+
+	if err := api.WriteContextFile(ctx, outputPath); err != nil {
+		return FlattenResult{}, fmt.Errorf("failed to write PDF: %w", err)
+	}
+
+	return result, nil
+}
+
+// flattenPage draws the selected annotations of a page into its content and removes them
+func flattenPage(ctx *model.Context, page int, opts FlattenOptions, result *FlattenResult) error {
+	pageDict, _, inherited, err := ctx.PageDict(page, false)
+	if err != nil {
+		return err
+	}
+
+	annots, err := ctx.DereferenceArray(pageDict["Annots"])
+	if err != nil || len(annots) == 0 {
+		return nil
+	}
+
+	var (
+		kept      types.Array
+		forms     []types.IndirectRef
+		placement []textMatrix
+	)
+	for _, o := range annots {
+		annot, err := ctx.DereferenceDict(o)
+		if err != nil || annot == nil {
+			continue
+		}
+
+		subtype := ""
+		if s := annot.NameEntry("Subtype"); s != nil {
+			subtype = *s
+		}
+		isWidget := subtype == "Widget"
+		switch {
+		case isWidget && opts.Forms:
+			result.Fields++
+		case subtype == "Popup" && opts.Annotations:
+			// Pop-up windows only show the text of another annotation
+			continue
+		case !isWidget && subtype != "Link" && opts.Annotations:
+			result.Annotations++
+		default:
+			kept = append(kept, o)
+			continue
+		}
+
+		if flags := annot.IntEntry("F"); flags != nil && *flags&(annotHidden|annotNoView) != 0 {
+			continue
+		}
+		if ref, cm := annotationAppearance(ctx, annot); ref != nil {
+			forms = append(forms, *ref)
+			placement = append(placement, cm)
+		}
+	}
+
+	if len(kept) == len(annots) {
+		return nil
+	}
+	if len(kept) > 0 {
+		pageDict["Annots"] = kept
+	} else {
+		delete(pageDict, "Annots")
+	}
+
+	if len(forms) == 0 {
+		return nil
+	}
+	names, err := addPageXObjects(ctx, pageDict, inherited, forms)
+	if err != nil {
+		return err
+	}
+
+	var content bytes.Buffer
+	for i, name := range names {
+		m := placement[i]
+		fmt.Fprintf(&content, "q %s %s %s %s %s %s cm /%s Do Q\n",
+			formatPDFNumber(m[0]), formatPDFNumber(m[1]), formatPDFNumber(m[2]),
+			formatPDFNumber(m[3]), formatPDFNumber(m[4]), formatPDFNumber(m[5]), name)
+	}
+	return appendPageContent(ctx, pageDict, content.Bytes())
+}
+
+// annotationAppearance returns the normal appearance stream of an annotation
+// and the matrix that fits it into the annotation's rectangle, or nil if the
+// annotation has nothing to show
+func annotationAppearance(ctx *model.Context, annot types.Dict) (*types.IndirectRef, textMatrix) {
+	ref := appearanceStream(ctx, annot)
+	if ref == nil {
+		// Forms filled by viewers that leave drawing to the next viewer have no
+		// appearance for their values
+		var err error
+		if ref, err = fieldAppearance(ctx, annot); err != nil || ref == nil {
+			return nil, textMatrix{}
+		}
+	}
+	sd, _, err := ctx.DereferenceStreamDict(*ref)
+	if err != nil || sd == nil {
+		return nil, textMatrix{}
+	}
+
+	rect := numberArray(ctx, annot["Rect"])
+	bbox := numberArray(ctx, sd.Dict["BBox"])
+	if len(rect) != 4 || len(bbox) != 4 {
+		return nil, textMatrix{}
+	}
+	matrix := identityMatrix
+	if m := numberArray(ctx, sd.Dict["Matrix"]); len(m) == 6 {
+		copy(matrix[:], m)
+	}
+
+	// The bounding box transformed by the form's own matrix is scaled and
+	// moved onto the rectangle, as described for annotation appearances
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, corner := range [][2]float64{{bbox[0], bbox[1]}, {bbox[2], bbox[1]}, {bbox[0], bbox[3]}, {bbox[2], bbox[3]}} {
+		x := matrix[0]*corner[0] + matrix[2]*corner[1] + matrix[4]
+		y := matrix[1]*corner[0] + matrix[3]*corner[1] + matrix[5]
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+	if maxX-minX == 0 || maxY-minY == 0 {
+		return nil, textMatrix{}
+	}
+
+	llx, urx := math.Min(rect[0], rect[2]), math.Max(rect[0], rect[2])
+	lly, ury := math.Min(rect[1], rect[3]), math.Max(rect[1], rect[3])
+	sx := (urx - llx) / (maxX - minX)
+	sy := (ury - lly) / (maxY - minY)
+
+	// Do needs a form XObject, which some writers do not label as one
+	sd.Dict["Type"] = types.Name("XObject")
+	sd.Dict["Subtype"] = types.Name("Form")
+
+	return ref, textMatrix{sx, 0, 0, sy, llx - minX*sx, lly - minY*sy}
+}
+
+// appearanceStream returns the normal appearance stream of an annotation, if it has one
+func appearanceStream(ctx *model.Context, annot types.Dict) *types.IndirectRef {
+	ap, err := ctx.DereferenceDict(annot["AP"])
+	if err != nil || ap == nil {
+		return nil
+	}
+
+	// Check boxes and radio buttons have one appearance per state
+	n := ap["N"]
+	if states, err := ctx.DereferenceDict(n); err == nil && states != nil {
+		as := annot.NameEntry("AS")
+		if as == nil {
+			return nil
+		}
+		n = states[*as]
+	}
+	ref, ok := n.(types.IndirectRef)
+	if !ok {
+		return nil
+	}
+	return &ref
+}
+
+// fieldAppearance creates an appearance stream that shows the value of a text
+// field, combo box or list box in Helvetica, in the size and color of the
+// field's default appearance. It returns nil for other annotations.
+func fieldAppearance(ctx *model.Context, annot types.Dict) (*types.IndirectRef, error) {
+	if s := annot.NameEntry("Subtype"); s == nil || *s != "Widget" {
+		return nil, nil
+	}
+	ft, _ := dereferenceObject(ctx, inheritedFieldEntry(ctx, annot, "FT")).(types.Name)
+	if ft != "Tx" && ft != "Ch" {
+		return nil, nil
+	}
+
+	var lines []string
+	switch v := dereferenceObject(ctx, inheritedFieldEntry(ctx, annot, "V")).(type) {
+	case types.StringLiteral, types.HexLiteral:
+		s, err := ctx.DereferenceStringOrHexLiteral(v, model.V10, nil)
+		if err != nil {
+			return nil, err
+		}
+		lines = strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	case types.Array:
+		// The selected options of a list box
+		for _, o := range v {
+			if s, err := ctx.DereferenceStringOrHexLiteral(o, model.V10, nil); err == nil {
+				lines = append(lines, s)
+			}
+		}
+	}
+	if len(lines) == 0 || (len(lines) == 1 && lines[0] == "") {
+		return nil, nil
+	}
+
+	rect := numberArray(ctx, annot["Rect"])
+	if len(rect) != 4 {
+		return nil, nil
+	}
+	w, h := math.Abs(rect[2]-rect[0]), math.Abs(rect[3]-rect[1])
+
+	// The default appearance sets the font size and text color
+	size, color := 0.0, "0 g"
+	if da, err := ctx.DereferenceStringOrHexLiteral(inheritedFieldEntry(ctx, annot, "DA"), model.V10, nil); err == nil {
+		ops, _ := parseContent([]byte(da))
+		for _, op := range ops {
+			switch op.name {
+			case "Tf":
+				if len(op.operands) == 2 {
+					size, _ = op.operands[1].(float64)
+				}
+			case "g", "rg", "k":
+				operands := make([]string, 0, len(op.operands))
+				for _, o := range op.operands {
+					if n, ok := o.(float64); ok {
+						operands = append(operands, formatPDFNumber(n))
+					}
+				}
+				color = strings.Join(append(operands, op.name), " ")
+			}
+		}
+	}
+	multiline := false
+	if ff := dereferenceNumber(ctx, inheritedFieldEntry(ctx, annot, "Ff")); ff != nil && ft == "Tx" {
+		multiline = int(*ff)&(1<<12) != 0
+	}
+	if !multiline && ft == "Tx" {
+		lines = lines[:1]
+	}
+	if size <= 0 {
+		// Auto size: fit the lines into the field, but no larger than 12 points
+		size = math.Min(12, (h-4)/(1.15*float64(len(lines))))
+		size = math.Max(size, 4)
+	}
+	alignment := 0.0
+	if q := dereferenceNumber(ctx, inheritedFieldEntry(ctx, annot, "Q")); q != nil {
+		alignment = *q
+	}
+
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "/Tx BMC q 1 1 %s %s re W n BT /Helv %s Tf %s\n",
+		formatPDFNumber(w-2), formatPDFNumber(h-2), formatPDFNumber(size), color)
+	leading := size * 1.15
+	y := (h-size)/2 + size*0.22
+	if len(lines) > 1 {
+		y = h - 2 - size
+	}
+	for i, line := range lines {
+		text := encodeWinAnsi(line)
+		width := 0.0
+		for _, c := range text {
+			width += float64(font.CharWidth("Helvetica", rune(c))) * size / 1000
+		}
+		x := 2.0
+		switch alignment {
+		case 1:
+			x = (w - width) / 2
+		case 2:
+			x = w - 2 - width
+		}
+		fmt.Fprintf(&content, "1 0 0 1 %s %s Tm (%s) Tj\n",
+			formatPDFNumber(x), formatPDFNumber(y-float64(i)*leading), escapePDFString(text))
+	}
+	content.WriteString("ET Q EMC\n")
+
+	sd, err := ctx.NewStreamDictForBuf(content.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	sd.Dict["Type"] = types.Name("XObject")
+	sd.Dict["Subtype"] = types.Name("Form")
+	sd.Dict["BBox"] = types.NewNumberArray(0, 0, w, h)
+	// pdfcpu only keeps fonts that are indirect objects when it writes a file
+	helv, err := ctx.IndRefForNewObject(types.Dict{
+		"Type":     types.Name("Font"),
+		"Subtype":  types.Name("Type1"),
+		"BaseFont": types.Name("Helvetica"),
+		"Encoding": types.Name("WinAnsiEncoding"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	sd.Dict["Resources"] = types.Dict{"Font": types.Dict{"Helv": *helv}}
+	if err := sd.Encode(); err != nil {
+		return nil, err
+	}
+	return ctx.IndRefForNewObject(*sd)
+}
+
+// inheritedFieldEntry returns an entry of a form field, which widgets inherit from their parent fields
+func inheritedFieldEntry(ctx *model.Context, d types.Dict, key string) types.Object {
+	for i := 0; d != nil && i < 32; i++ {
+		if o, found := d.Find(key); found {
+			return o
+		}
+		parent, err := ctx.DereferenceDict(d["Parent"])
+		if err != nil {
+			return nil
+		}
+		d = parent
+	}
+	if key == "DA" && ctx.Form != nil {
+		// The form's default appearance applies to all its fields
+		return ctx.Form["DA"]
+	}
+	return nil
+}
+
+// encodeWinAnsi encodes text for a font with WinAnsiEncoding, replacing characters it lacks
+func encodeWinAnsi(s string) []byte {
+	var b []byte
+	for _, r := range s {
+		c, ok := charmap.Windows1252.EncodeRune(r)
+		if !ok {
+			c = '?'
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+// escapePDFString escapes the delimiters of a literal string
+func escapePDFString(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		if c == '(' || c == ')' || c == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// addPageXObjects adds form XObjects to a page's resources and returns their
+// names. The resources are copied first, as they may be shared with or
+// inherited from other pages.
+func addPageXObjects(ctx *model.Context, pageDict types.Dict, inherited *model.InheritedPageAttrs, forms []types.IndirectRef) ([]string, error) {
+	res := inherited.Resources
+	if o, found := pageDict.Find("Resources"); found {
+		d, err := ctx.DereferenceDict(o)
+		if err != nil {
+			return nil, err
+		}
+		res = d
+	}
+
+	newRes := types.Dict{}
+	for k, v := range res {
+		newRes[k] = v
+	}
+	xObjects := types.Dict{}
+	if d, err := ctx.DereferenceDict(newRes["XObject"]); err == nil {
+		for k, v := range d {
+			xObjects[k] = v
+		}
+	}
+
+	var names []string
+	for _, ref := range forms {
+		// Skip names the page content already uses
+		name := fmt.Sprintf("Fl%d", len(names))
+		for i := 0; xObjects[name] != nil; i++ {
+			name = fmt.Sprintf("Fl%d_%d", len(names), i)
+		}
+		xObjects[name] = ref
+		names = append(names, name)
+	}
+
+	newRes["XObject"] = xObjects
+	pageDict["Resources"] = newRes
+	return names, nil
+}
+
+// appendPageContent draws content on top of a page. The existing content is
+// wrapped in q/Q so changes it makes to the graphics state do not carry over.
+func appendPageContent(ctx *model.Context, pageDict types.Dict, content []byte) error {
+	var contents types.Array
+	switch o := dereferenceObject(ctx, pageDict["Contents"]).(type) {
+	case types.StreamDict:
+		contents = types.Array{pageDict["Contents"]}
+	case types.Array:
+		contents = append(contents, o...)
+	}
+
+	before, err := ctx.StreamDictIndRef([]byte("q\n"))
+	if err != nil {
+		return err
+	}
+	after, err := ctx.StreamDictIndRef(append([]byte("\nQ\n"), content...))
+	if err != nil {
+		return err
+	}
+
+	pageDict["Contents"] = append(append(types.Array{*before}, contents...), *after)
+	return nil
+}
+
+// numberArray returns the numbers of an array, or nil if it holds anything else
+func numberArray(ctx *model.Context, o types.Object) []float64 {
+	a, err := ctx.DereferenceArray(o)
+	if err != nil {
+		return nil
+	}
+	numbers := make([]float64, len(a))
+	for i, v := range a {
+		n := dereferenceNumber(ctx, v)
+		if n == nil {
+			return nil
+		}
+		numbers[i] = *n
+	}
+	return numbers
+}
+
+// formatPDFNumber writes a number as a content stream operand, without an exponent
+func formatPDFNumber(v float64) string {
+	// Rounding drops the noise of float arithmetic, such as 21.871999999999957
+	return strconv.FormatFloat(math.Round(v*10000)/10000, 'f', -1, 64)
+}
@@ -0,0 +1,341 @@
+package pdf
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/form"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// FormField describes a field of a PDF form
+type FormField struct {
+	Name      string   `json:"name"`                // Fully qualified name, used to fill the field
+	Type      string   `json:"type"`                // "text", "date", "checkbox", "radio", "combobox" or "listbox"
+	Label     string   `json:"label,omitempty"`     // Alternate name that viewers show as a tooltip
+	Pages     []int    `json:"pages"`               // Pages the field appears on
+	Options   []string `json:"options,omitempty"`   // Choices of radio buttons, combo boxes and list boxes
+	Value     string   `json:"value"`               // "true" or "false" for check boxes; list box values are comma separated
+	Format    string   `json:"format,omitempty"`    // Date format, e.g. "dd.mm.yyyy"
+	MaxLen    int      `json:"maxLen,omitempty"`    // Maximum length of a text field
+	Multiline bool     `json:"multiline,omitempty"` // A text field that takes several lines
+	Multiple  bool     `json:"multiple,omitempty"`  // A list box that allows several values
+	Editable  bool     `json:"editable,omitempty"`  // A combo box that also accepts values that are not options
+	Locked    bool     `json:"locked,omitempty"`    // A read-only field
+}
+
+// ListFormFields returns the fields of a PDF form in the order they appear in the document
+func ListFormFields(inputPath string) ([]FormField, error) {
+	ctx, err := readFormContext(inputPath, model.LISTFORMFIELDS)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := exportForm(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []FormField
+	for _, tf := range f.TextFields {
+		fields = append(fields, FormField{Name: tf.Name, Type: "text", Label: tf.AltName, Pages: tf.Pages, Value: tf.Value, MaxLen: tf.MaxLen, Multiline: tf.Multiline, Locked: tf.Locked})
+	}
+	for _, df := range f.DateFields {
+		fields = append(fields, FormField{Name: df.Name, Type: "date", Label: df.AltName, Pages: df.Pages, Value: df.Value, Format: df.Format, Locked: df.Locked})
+	}
+	for _, cb := range f.CheckBoxes {
+		fields = append(fields, FormField{Name: cb.Name, Type: "checkbox", Label: cb.AltName, Pages: cb.Pages, Value: strconv.FormatBool(cb.Value), Locked: cb.Locked})
+	}
+	for _, rb := range f.RadioButtonGroups {
+		fields = append(fields, FormField{Name: rb.Name, Type: "radio", Label: rb.AltName, Pages: rb.Pages, Options: rb.Options, Value: rb.Value, Locked: rb.Locked})
+	}
+	for _, cb := range f.ComboBoxes {
+		fields = append(fields, FormField{Name: cb.Name, Type: "combobox", Label: cb.AltName, Pages: cb.Pages, Options: cb.Options, Value: cb.Value, Editable: cb.Editable, Locked: cb.Locked})
+	}
+	for _, lb := range f.ListBoxes {
+		fields = append(fields, FormField{Name: lb.Name, Type: "listbox", Label: lb.AltName, Pages: lb.Pages, Options: lb.Options, Value: strings.Join(lb.Values, ","), Multiple: lb.Multi, Locked: lb.Locked})
+	}
+
+	// pdfcpu groups the fields by type and exports each page in no particular
+	// order, so restore the order of the widgets in the document
+	order := map[string]int{}
+	if listed, _, err := form.FormFields(ctx); err == nil {
+		for i, lf := range listed {
+			if _, ok := order[lf.Name]; !ok {
+				order[lf.Name] = i
+			}
+		}
+	}
+	slices.SortStableFunc(fields, func(a, b FormField) int {
+		return order[a.Name] - order[b.Name]
+	})
+
+	return fields, nil
+}
+
+// FillForm writes a copy of a PDF form with the fields named in values set.
+// Check boxes take "true" or "false" (also yes/no, on/off, 1/0), list boxes a
+// comma separated list of options. Fields that are not named keep their value.
+func FillForm(inputPath, outputPath string, values map[string]string) error {
+	if len(values) == 0 {
+		return fmt.Errorf("no values to fill in")
+	}
+
+	ctx, err := readFormContext(inputPath, model.FILLFORMFIELDS)
+	if err != nil {
+		return err
+	}
+
+	if err := fillForm(ctx, values); err != nil {
+		return err
+	}
+
+	if err := api.WriteContextFile(ctx, outputPath); err != nil {
+		return fmt.Errorf("failed to write PDF: %w", err)
+	}
+
+	return nil
+}
+
+// FillFormCSV fills a copy of a PDF form for every record of a CSV file and
+// returns the path of a ZIP with the filled forms and how many it holds.
+// The first row names the fields; empty cells leave a field as it is in the form.
+func FillFormCSV(inputPath, csvPath, outputDir string) (string, int, error) {
+	names, records, err := readFormCSV(csvPath)
+	if err != nil {
+		return "", 0, err
+	}
+
+	formsDir, err := os.MkdirTemp(outputDir, "forms_")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	defer os.RemoveAll(formsDir)
+
+	width := len(strconv.Itoa(len(records)))
+	for i, record := range records {
+		values := map[string]string{}
+		for j, name := range names {
+			if j < len(record) && record[j] != "" {
+				values[name] = record[j]
+			}
+		}
+		// A record without values is a copy of the form as it is
+		if len(values) == 0 {
+			values = nil
+		}
+
+		// Row 1 holds the field names
+		outputPath := filepath.Join(formsDir, fmt.Sprintf("form_%0*d.pdf", width, i+1))
+		if err := fillFormRecord(inputPath, outputPath, values); err != nil {
+			return "", 0, fmt.Errorf("row %d: %w", i+2, err)
+		}
+	}
+
+	zipPath := filepath.Join(outputDir, filepath.Base(formsDir)+".zip")
+	if err := zipDirectory(formsDir, zipPath); err != nil {
+		os.Remove(zipPath)
+		return "", 0, fmt.Errorf("failed to create ZIP: %w", err)
+	}
+
+	return zipPath, len(records), nil
+}
+
+// fillFormRecord fills one copy of a form, which may be left unchanged
+func fillFormRecord(inputPath, outputPath string, values map[string]string) error {
+	ctx, err := readFormContext(inputPath, model.FILLFORMFIELDS)
+	if err != nil {
+		return err
+	}
+
+	if len(values) > 0 {
+		if err := fillForm(ctx, values); err != nil {
+			return err
+		}
+	}
+
+	if err := api.WriteContextFile(ctx, outputPath); err != nil {
+		return fmt.Errorf("failed to write PDF: %w", err)
+	}
+
+	return nil
+}
+
+// readFormCSV reads the field names in the first row of a CSV file and the records below them
+func readFormCSV(csvPath string) ([]string, [][]string, error) {
+	f, err := os.Open(csvPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open CSV: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+
+	names, err := r.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("the CSV is empty")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	// Spreadsheet applications often start UTF-8 files with a byte order mark
+	names[0] = strings.TrimPrefix(names[0], "\ufeff")
+	for i, name := range names {
+		names[i] = strings.TrimSpace(name)
+		if names[i] == "" {
+			return nil, nil, fmt.Errorf("column %d of the first row has no field name", i+1)
+		}
+	}
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("the CSV has no records below the field names")
+	}
+
+	return names, records, nil
+}
+
+// readFormContext reads and validates a PDF file for a form command
+func readFormContext(inputPath string, cmd model.CommandMode) (*model.Context, error) {
+	conf := model.NewDefaultConfiguration()
+	conf.Cmd = cmd
+
+	ctx, err := readValidateAndOptimizeFile(inputPath, conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	if ctx.Form == nil {
+		return nil, fmt.Errorf("the PDF has no form")
+	}
+	return ctx, nil
+}
+
+// exportForm returns the fields of ctx with their current values
+func exportForm(ctx *model.Context) (*form.Form, error) {
+	group, ok, err := form.ExportForm(ctx.XRefTable, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read form: %w", err)
+	}
+	if !ok || len(group.Forms) == 0 {
+		return nil, fmt.Errorf("the PDF has no form fields")
+	}
+	return &group.Forms[0], nil
+}
+
+// fillForm sets the fields named in values and updates their appearance.
+// pdfcpu fills a whole form at once, so the other fields are given their current values.
+func fillForm(ctx *model.Context, values map[string]string) error {
+	f, err := exportForm(ctx)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range values {
+		if err := setFormValue(f, name, value); err != nil {
+			return err
+		}
+	}
+
+	// A signature would no longer match the filled form
+	ctx.RemoveSignature()
+
+	if _, _, err := form.FillForm(ctx, form.FillDetails(f, nil), nil, form.JSON); err != nil {
+		return fmt.Errorf("failed to fill form: %w", err)
+	}
+
+	return nil
+}
+
+// setFormValue checks value against the field called name and sets it in f
+func setFormValue(f *form.Form, name, value string) error {
+	for _, tf := range f.TextFields {
+		if tf.Name == name {
+			if tf.MaxLen > 0 && utf8.RuneCountInString(value) > tf.MaxLen {
+				return fmt.Errorf("%s takes at most %d characters", name, tf.MaxLen)
+			}
+			tf.Value = value
+			return nil
+		}
+	}
+	for _, df := range f.DateFields {
+		if df.Name == name {
+			df.Value = value
+			return nil
+		}
+	}
+	for _, cb := range f.CheckBoxes {
+		if cb.Name == name {
+			checked, err := parseFormBool(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			cb.Value = checked
+			return nil
+		}
+	}
+	for _, rb := range f.RadioButtonGroups {
+		if rb.Name == name {
+			if !slices.Contains(rb.Options, value) {
+				return fmt.Errorf("%s must be one of %s", name, strings.Join(rb.Options, ", "))
+			}
+			rb.Value = value
+			return nil
+		}
+	}
+	for _, cb := range f.ComboBoxes {
+		if cb.Name == name {
+			if !cb.Editable && value != "" && !slices.Contains(cb.Options, value) {
+				return fmt.Errorf("%s must be one of %s", name, strings.Join(cb.Options, ", "))
+			}
+			cb.Value = value
+			return nil
+		}
+	}
+	for _, lb := range f.ListBoxes {
+		if lb.Name == name {
+			var selected []string
+			for _, v := range strings.Split(value, ",") {
+				v = strings.TrimSpace(v)
+				if v == "" {
+					continue
+				}
+				if !slices.Contains(lb.Options, v) {
+					return fmt.Errorf("%s must be one of %s", name, strings.Join(lb.Options, ", "))
+				}
+				selected = append(selected, v)
+			}
+			if len(selected) == 0 {
+				return fmt.Errorf("%s needs at least one of %s", name, strings.Join(lb.Options, ", "))
+			}
+			if len(selected) > 1 && !lb.Multi {
+				return fmt.Errorf("%s takes only one value", name)
+			}
+			lb.Values = selected
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown form field %q", name)
+}
+
+// parseFormBool parses the value of a check box
+func parseFormBool(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "yes", "on", "1", "x":
+		return true, nil
+	case "false", "no", "off", "0", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("expected true or false, got %q", value)
+}
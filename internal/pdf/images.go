@@ -0,0 +1,177 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"lovepdf/internal/image"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// ExtractImagesOptions selects which images are extracted and how they are written
+type ExtractImagesOptions struct {
+	Pages     string // PageSelector expression of the pages to extract from; empty for all pages
+	MinWidth  int    // Skip images narrower than this many pixels
+	MinHeight int    // Skip images shorter than this many pixels
+	Quality   int    // Re-encode JPEG and PNG images through image.CompressImage at this quality (1-100); 0 keeps them as embedded
+}
+
+// ExtractImages writes every embedded image of the selected pages to a ZIP and
+// returns its path and the number of images in it. JPEG images are copied
+// unchanged, other images are converted to PNG (or TIFF for CMYK). Files are
+// named after the first page an image appears on and its object number, e.g.
+// "page_03_obj_12.jpg". Inline images and soft masks are not extracted.
+func ExtractImages(inputPath, outputDir string, opts ExtractImagesOptions) (string, int, error) {
+	if opts.Quality < 0 || opts.Quality > 100 {
+		return "", 0, fmt.Errorf("quality must be between 1 and 100")
+	}
+
+	ctx, err := readImagesContext(inputPath)
+	if err != nil {
+		return "", 0, err
+	}
+
+	pages, err := selectPages(opts.Pages, ctx.PageCount)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid page range: %w", err)
+	}
+
+	imagesDir, err := os.MkdirTemp(outputDir, "images_")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	defer os.RemoveAll(imagesDir)
+
+	masks := softMaskObjNrs(ctx)
+	width := len(strconv.Itoa(ctx.PageCount))
+	done := map[int]bool{}
+	count := 0
+
+	for _, page := range pages {
+		objNrs := pdfcpu.ImageObjNrs(ctx, page)
+		slices.Sort(objNrs)
+
+		for _, objNr := range objNrs {
+			// Images shared by several pages are only extracted once
+			if done[objNr] || masks[objNr] {
+				continue
+			}
+			done[objNr] = true
+
+			imageObj := ctx.Optimize.ImageObjects[objNr]
+			if imageObj == nil || imageObj.ImageDict == nil {
+				continue
+			}
+
+			w, h := imageSize(ctx, imageObj.ImageDict)
+			if w < opts.MinWidth || h < opts.MinHeight {
+				continue
+			}
+
+			img, err := pdfcpu.ExtractImage(ctx, imageObj.ImageDict, false, imageObj.ResourceNames[page-1], objNr, false)
+			if err != nil {
+				return "", 0, fmt.Errorf("failed to extract image %d on page %d: %w", objNr, page, err)
+			}
+			// Images with unsupported filters come back without content
+			if img == nil || img.Reader == nil {
+				continue
+			}
+
+			name := fmt.Sprintf("page_%0*d_obj_%d.%s", width, page, objNr, img.FileType)
+			path := filepath.Join(imagesDir, name)
+			if err := pdfcpu.WriteReader(path, img.Reader); err != nil {
+				return "", 0, fmt.Errorf("failed to write %s: %w", name, err)
+			}
+
+			if opts.Quality > 0 {
+				if err := recompressImage(path, opts.Quality); err != nil {
+					return "", 0, fmt.Errorf("failed to compress %s: %w", name, err)
+				}
+			}
+			count++
+		}
+	}
+
+	if count == 0 {
+		return "", 0, fmt.Errorf("no images found on the selected pages")
+	}
+
+	zipPath := filepath.Join(outputDir, filepath.Base(imagesDir)+".zip")
+	if err := zipDirectory(imagesDir, zipPath); err != nil {
+		os.Remove(zipPath)
+		return "", 0, fmt.Errorf("failed to create ZIP: %w", err)
+	}
+
+	return zipPath, count, nil
+}
+
+// readImagesContext reads a PDF file and collects the images used by each page
+func readImagesContext(inputPath string) (*model.Context, error) {
+	conf := model.NewDefaultConfiguration()
+	conf.Cmd = model.EXTRACTIMAGES
+
+	ctx, err := readValidateAndOptimizeFile(inputPath, conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	return ctx, nil
+}
+
+// softMaskObjNrs returns the object numbers of the transparency masks of all images
+func softMaskObjNrs(ctx *model.Context) map[int]bool {
+	masks := map[int]bool{}
+	for _, imageObj := range ctx.Optimize.ImageObjects {
+		if imageObj == nil || imageObj.ImageDict == nil {
+			continue
+		}
+		if ref := imageObj.ImageDict.IndirectRefEntry("SMask"); ref != nil {
+			masks[ref.ObjectNumber.Value()] = true
+		}
+	}
+	return masks
+}
+
+// imageSize returns the width and height of an image in pixels
+func imageSize(ctx *model.Context, sd *types.StreamDict) (int, int) {
+	var size [2]int
+	for i, key := range []string{"Width", "Height"} {
+		if n, err := ctx.DereferenceInteger(sd.Dict[key]); err == nil && n != nil {
+			size[i] = n.Value()
+		}
+	}
+	return size[0], size[1]
+}
+
+// recompressImage re-encodes a JPEG or PNG file in place, keeping the original if it is smaller
+func recompressImage(path string, quality int) error {
+	ext := filepath.Ext(path)
+	if ext != ".jpg" && ext != ".png" {
+		return nil
+	}
+
+	compressedPath := path + ".tmp" + ext
+	defer os.Remove(compressedPath)
+
+	if err := image.CompressImage(path, compressedPath, image.CompressionOptions{Quality: quality, Format: "same"}); err != nil {
+		return err
+	}
+
+	original, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	compressed, err := os.Stat(compressedPath)
+	if err != nil {
+		return err
+	}
+	if compressed.Size() >= original.Size() {
+		return nil
+	}
+	return os.Rename(compressedPath, path)
+}
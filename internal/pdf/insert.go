@@ -0,0 +1,149 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// InsertOptions describes where to insert pages and what to insert.
+// Blank pages are inserted unless InsertPath is set.
+type InsertOptions struct {
+	Pages      string // PageSelector expression of the pages to insert next to, e.g. "1" or "3,last"
+	Every      int    // Insert after every N pages instead of next to Pages; 0 to use Pages
+	Before     bool   // Insert before the pages instead of after them
+	Count      int    // Number of blank pages inserted at each position
+	PaperSize  string // Paper size of blank pages, e.g. "A4" or "Letter"; empty to match the neighboring page
+	InsertPath string // PDF whose pages are inserted at each position
+}
+
+// insertPaperSizes are the paper sizes offered for blank pages
+var insertPaperSizes = []string{"A3", "A4", "A5", "Letter", "Legal", "Tabloid"}
+
+// InsertPages inserts blank pages or the pages of another PDF into a PDF file
+func InsertPages(inputPath, outputPath string, opts InsertOptions) error {
+	pageCount, err := api.PageCountFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	targets, err := insertTargets(opts, pageCount)
+	if err != nil {
+		return err
+	}
+
+	if opts.InsertPath != "" {
+		return insertDocument(inputPath, outputPath, opts.InsertPath, targets, opts.Before, pageCount)
+	}
+	return insertBlankPages(inputPath, outputPath, targets, opts)
+}
+
+// insertTargets resolves the pages that insertions are made next to
+func insertTargets(opts InsertOptions, pageCount int) ([]int, error) {
+	if opts.Every < 0 {
+		return nil, fmt.Errorf("every must not be negative")
+	}
+
+	if opts.Every == 0 {
+		if strings.TrimSpace(opts.Pages) == "" {
+			return nil, fmt.Errorf("no pages specified")
+		}
+		pages, err := selectPages(opts.Pages, pageCount)
+		if err != nil {
+			return nil, fmt.Errorf("invalid page range: %w", err)
+		}
+		return pages, nil
+	}
+
+	// Insertions go between groups of N pages, not after the last page
+	var targets []int
+	for page := opts.Every; page < pageCount; page += opts.Every {
+		if opts.Before {
+			targets = append(targets, page+1)
+		} else {
+			targets = append(targets, page)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("document has %d pages, so there is no place to insert after every %d pages", pageCount, opts.Every)
+	}
+	return targets, nil
+}
+
+// insertBlankPages inserts opts.Count blank pages at each target
+func insertBlankPages(inputPath, outputPath string, targets []int, opts InsertOptions) error {
+	if opts.Count < 1 || opts.Count > 100 {
+		return fmt.Errorf("number of blank pages must be between 1 and 100")
+	}
+
+	// A nil dimension makes pdfcpu copy the size of the neighboring page
+	var dim *types.Dim
+	if opts.PaperSize != "" {
+		if !slices.Contains(insertPaperSizes, opts.PaperSize) {
+			return fmt.Errorf("unsupported paper size: %s", opts.PaperSize)
+		}
+		dim = types.PaperSize[opts.PaperSize]
+	}
+
+	ctx, err := api.ReadContextFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	// Work from the back so inserted pages do not shift the targets still to come
+	for i := len(targets) - 1; i >= 0; i-- {
+		for n := 0; n < opts.Count; n++ {
+			if err := ctx.InsertBlankPages(types.IntSet{targets[i]: true}, dim, opts.Before); err != nil {
+				return fmt.Errorf("failed to insert blank pages: %w", err)
+			}
+		}
+	}
+
+	if err := api.WriteContextFile(ctx, outputPath); err != nil {
+		return fmt.Errorf("failed to write PDF: %w", err)
+	}
+
+	return nil
+}
+
+// insertDocument inserts all pages of insertPath at each target
+func insertDocument(inputPath, outputPath, insertPath string, targets []int, before bool, pageCount int) error {
+	insertCount, err := api.PageCountFile(insertPath)
+	if err != nil {
+		return fmt.Errorf("failed to read PDF to insert: %w", err)
+	}
+
+	// Append the inserted document, then arrange its pages around the targets
+	mergedPath := outputPath + ".merged"
+	defer os.Remove(mergedPath)
+
+	conf := model.NewDefaultConfiguration()
+	conf.CreateBookmarks = false
+	if err := api.MergeCreateFile([]string{inputPath, insertPath}, mergedPath, false, conf); err != nil {
+		return fmt.Errorf("failed to combine PDFs: %w", err)
+	}
+
+	inserted := pageSpan(pageCount+1, pageCount+insertCount)
+	var order []int
+	for page := 1; page <= pageCount; page++ {
+		isTarget := slices.Contains(targets, page)
+		if isTarget && before {
+			order = append(order, inserted...)
+		}
+		order = append(order, page)
+		if isTarget && !before {
+			order = append(order, inserted...)
+		}
+	}
+
+	if err := ReorderPages(mergedPath, outputPath, order); err != nil {
+		return fmt.Errorf("failed to insert pages: %w", err)
+	}
+
+	return nil
+}
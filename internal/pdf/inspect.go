@@ -0,0 +1,195 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/form"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// DocumentInfo describes the structure of a PDF file
+type DocumentInfo struct {
+	Version     string           `json:"version"`
+	PageCount   int              `json:"pageCount"`
+	Pages       []PageInfo       `json:"pages"`
+	Encrypted   bool             `json:"encrypted"`
+	Encryption  string           `json:"encryption,omitempty"`  // Algorithm and key length, e.g. "AES-256"
+	Permissions *Permissions     `json:"permissions,omitempty"` // Only set for encrypted documents
+	Linearized  bool             `json:"linearized"`
+	Tagged      bool             `json:"tagged"`
+	Fonts       []FontInfo       `json:"fonts"`
+	ImageCount  int              `json:"imageCount"`
+	Attachments []AttachmentInfo `json:"attachments"`
+	FormFields  []FormFieldInfo  `json:"formFields"`
+}
+
+// PageInfo describes the boxes and rotation of a page.
+// Boxes are [llx lly urx ury] in points.
+type PageInfo struct {
+	Number   int        `json:"number"`
+	MediaBox [4]float64 `json:"mediaBox"`
+	CropBox  [4]float64 `json:"cropBox"`
+	Rotation int        `json:"rotation"`
+}
+
+// FontInfo describes a font used by the document
+type FontInfo struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Embedded bool   `json:"embedded"`
+	Subset   bool   `json:"subset"`
+}
+
+// AttachmentInfo describes an embedded file
+type AttachmentInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// FormFieldInfo describes a form field
+type FormFieldInfo struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Pages []int  `json:"pages"`
+}
+
+// Inspect reads the structure of a PDF file.
+// password is the user or owner password of documents that need one to open and may be empty.
+func Inspect(inputPath, password string) (info DocumentInfo, err error) {
+	var ctx *model.Context
+	for _, conf := range passwordConfigs(password) {
+		conf.ValidationMode = model.ValidationRelaxed
+		conf.Cmd = model.LISTINFO
+
+		// Optimizing collects the fonts and images
+		ctx, err = readValidateAndOptimizeFile(inputPath, conf)
+		if !errors.Is(err, pdfcpu.ErrWrongPassword) {
+			break
+		}
+	}
+	if err != nil {
+		return DocumentInfo{}, fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	version := ctx.HeaderVersion
+	if ctx.RootVersion != nil {
+		version = ctx.RootVersion
+	}
+
+	info = DocumentInfo{
+		Version:    version.String(),
+		PageCount:  ctx.PageCount,
+		Encrypted:  ctx.Encrypt != nil,
+		Linearized: ctx.Read.Linearized,
+		Tagged:     ctx.Tagged,
+		ImageCount: len(ctx.Optimize.ImageObjects),
+	}
+
+	boundaries, err := ctx.PageBoundaries(nil)
+	if err != nil {
+		return DocumentInfo{}, fmt.Errorf("failed to read page boxes: %w", err)
+	}
+	for i, pb := range boundaries {
+		info.Pages = append(info.Pages, PageInfo{
+			Number:   i + 1,
+			MediaBox: boxArray(pb.MediaBox()),
+			CropBox:  boxArray(pb.CropBox()),
+			Rotation: pb.Rot,
+		})
+	}
+
+	if ctx.E != nil {
+		info.Encryption = encryptionName(ctx)
+		perms := permissionsFromBits(ctx.E.P)
+		info.Permissions = &perms
+	}
+
+	info.Fonts = documentFonts(ctx)
+
+	attachments, err := ctx.ListAttachments()
+	if err != nil {
+		return DocumentInfo{}, fmt.Errorf("failed to read attachments: %w", err)
+	}
+	info.Attachments = []AttachmentInfo{}
+	for _, a := range attachments {
+		info.Attachments = append(info.Attachments, AttachmentInfo{Name: a.FileName, Description: a.Desc})
+	}
+
+	info.FormFields = []FormFieldInfo{}
+	if ctx.Form != nil {
+		fields, _, err := form.FormFields(ctx)
+		if err != nil {
+			return DocumentInfo{}, fmt.Errorf("failed to read form fields: %w", err)
+		}
+		for _, field := range fields {
+			info.FormFields = append(info.FormFields, FormFieldInfo{Name: field.Name, Type: field.Typ.String(), Pages: field.Pages})
+		}
+	}
+
+	return info, nil
+}
+
+// readValidateAndOptimizeFile reads, validates and optimizes a PDF file
+func readValidateAndOptimizeFile(path string, conf *model.Configuration) (*model.Context, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return api.ReadValidateAndOptimize(f, conf)
+}
+
+// boxArray returns a rectangle as [llx lly urx ury]
+func boxArray(r *types.Rectangle) [4]float64 {
+	if r == nil {
+		return [4]float64{}
+	}
+	return [4]float64{r.LL.X, r.LL.Y, r.UR.X, r.UR.Y}
+}
+
+// encryptionName names the algorithm and key length of an encrypted document
+func encryptionName(ctx *model.Context) string {
+	if ctx.AES4Streams {
+		if ctx.E.V == 5 {
+			return "AES-256"
+		}
+		return "AES-128"
+	}
+
+	// RC4 keys default to 40 bits when no length is given
+	length := ctx.E.L
+	if length == 0 {
+		length = 40
+	}
+	return fmt.Sprintf("RC4-%d", length)
+}
+
+// documentFonts lists the fonts collected while optimizing, sorted by name
+func documentFonts(ctx *model.Context) []FontInfo {
+	var names []string
+	for name := range ctx.Optimize.Fonts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fonts := []FontInfo{}
+	for _, name := range names {
+		for _, objNr := range ctx.Optimize.Fonts[name] {
+			font := ctx.Optimize.FontObjects[objNr]
+			fonts = append(fonts, FontInfo{
+				Name:     font.FontName,
+				Type:     font.SubType(),
+				Embedded: font.Embedded,
+				Subset:   font.Prefix != "",
+			})
+		}
+	}
+	return fonts
+}
@@ -2,27 +2,330 @@ package pdf
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
 )
 
+// MergeSource is an input file and the name it was uploaded with
+type MergeSource struct {
+	Path          string
+	Name          string // Original file name, used for bookmarks and the table of contents
+	Pages         string // PageSelector expression of the pages to include; empty for all pages
+	DropBookmarks bool   // Leave out the file's own bookmarks, which are otherwise kept
+}
+
+// MergeOptions controls the outline and table of contents of a merged PDF
+type MergeOptions struct {
+	FileBookmarks   bool // Add one top-level bookmark per source file, titled with its name, above its own bookmarks
+	TableOfContents bool // Add a page at the front listing each file and the page it starts on
+	Interleave      bool // Alternate the pages of exactly two files (A1, B1, A2, B2, ...)
+	ReverseSecond   bool // Take the second file's pages last to first when interleaving
+}
+
+// tocFontSize is the font size of the generated table of contents
+const tocFontSize = 12
+
 // MergePDFs combines multiple PDF files into one
-func MergePDFs(inputPaths []string, outputPath string) error {
-	if len(inputPaths) < 2 {
+func MergePDFs(sources []MergeSource, outputPath string, opts MergeOptions) error {
+	if len(sources) < 2 {
 		return fmt.Errorf("at least 2 PDF files are required for merging")
 	}
 
+	if opts.Interleave && len(sources) != 2 {
+		return fmt.Errorf("interleaving needs exactly 2 PDF files, got %d", len(sources))
+	}
+
 	// Validate all input files exist
-	for _, path := range inputPaths {
-		if path == "" {
+	var inputPaths []string
+	for _, src := range sources {
+		if src.Path == "" {
 			return fmt.Errorf("invalid file path")
 		}
+		inputPaths = append(inputPaths, src.Path)
+	}
+
+	// Collect page counts and bookmarks before the sources lose their identity
+	entries := make([]pdfcpu.Bookmark, len(sources))
+	counts := make([]int, len(sources))
+	page := 1
+	for i, src := range sources {
+		count, err := api.PageCountFile(src.Path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", mergeTitle(src), err)
+		}
+
+		pages, err := selectPages(src.Pages, count)
+		if err != nil {
+			return fmt.Errorf("invalid page range for %s: %w", mergeTitle(src), err)
+		}
+
+		// Copy the selected pages to a temporary file that is merged instead
+		if len(pages) < count {
+			partPath := fmt.Sprintf("%s.part%d", outputPath, i)
+			defer os.Remove(partPath)
+
+			if err := ReorderPages(src.Path, partPath, pages); err != nil {
+				return fmt.Errorf("failed to select pages of %s: %w", mergeTitle(src), err)
+			}
+			inputPaths[i] = partPath
+		}
+		counts[i] = len(pages)
+
+		entries[i] = pdfcpu.Bookmark{Title: mergeTitle(src), PageFrom: page}
+		if !src.DropBookmarks {
+			bms, err := sourceBookmarks(src.Path)
+			if err != nil {
+				return fmt.Errorf("failed to read bookmarks of %s: %w", mergeTitle(src), err)
+			}
+
+			// Position of each original page in the merged document, 0 if left out
+			position := make([]int, count+1)
+			for j, p := range pages {
+				position[p] = page + j
+			}
+			entries[i].Kids = selectBookmarks(bms, position)
+		}
+		page += counts[i]
 	}
 
-	// Merge PDFs using pdfcpu
-	if err := api.MergeCreateFile(inputPaths, outputPath, false, nil); err != nil {
+	// Merge PDFs using pdfcpu; the outline is rebuilt below
+	conf := model.NewDefaultConfiguration()
+	conf.CreateBookmarks = false
+
+	if opts.Interleave {
+		// Append the files, then pick their pages alternately
+		mergedPath := outputPath + ".merged"
+		defer os.Remove(mergedPath)
+
+		if err := api.MergeCreateFile(inputPaths, mergedPath, false, conf); err != nil {
+			return fmt.Errorf("failed to merge PDFs: %w", err)
+		}
+
+		order := interleaveOrder(counts[0], counts[1], opts.ReverseSecond)
+		if err := ReorderPages(mergedPath, outputPath, order); err != nil {
+			return fmt.Errorf("failed to interleave pages: %w", err)
+		}
+
+		// Point the bookmarks at the pages' new positions
+		position := make([]int, len(order)+1)
+		for i, page := range order {
+			position[page] = i + 1
+		}
+		entries[0].PageFrom = 1
+		entries[1].PageFrom = min(position[counts[0]+1], position[counts[0]+counts[1]])
+		for i := range entries {
+			moveBookmarks(entries[i].Kids, position)
+		}
+	} else if err := api.MergeCreateFile(inputPaths, outputPath, false, conf); err != nil {
 		return fmt.Errorf("failed to merge PDFs: %w", err)
 	}
 
+	if opts.TableOfContents {
+		if err := addTableOfContents(outputPath, entries); err != nil {
+			return fmt.Errorf("failed to add table of contents: %w", err)
+		}
+		shiftBookmarks(entries, 1)
+	}
+
+	var outline []pdfcpu.Bookmark
+	for _, entry := range entries {
+		if opts.FileBookmarks {
+			outline = append(outline, entry)
+		} else {
+			outline = append(outline, entry.Kids...)
+		}
+	}
+
+	if err := replaceOutline(outputPath, outline); err != nil {
+		return fmt.Errorf("failed to write bookmarks: %w", err)
+	}
+
 	return nil
 }
+
+// mergeTitle returns the name shown for a source in bookmarks and the table of contents
+func mergeTitle(src MergeSource) string {
+	name := filepath.Base(src.Name)
+	if src.Name == "" {
+		name = filepath.Base(src.Path)
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// sourceBookmarks reads the bookmarks of a PDF file
+func sourceBookmarks(path string) ([]pdfcpu.Bookmark, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return api.Bookmarks(f, nil)
+}
+
+// selectBookmarks points bookmarks at their pages' positions in the merged document.
+// Bookmarks on pages that were left out are dropped and their children take their place.
+func selectBookmarks(bms []pdfcpu.Bookmark, position []int) []pdfcpu.Bookmark {
+	var result []pdfcpu.Bookmark
+	for _, bm := range bms {
+		kids := selectBookmarks(bm.Kids, position)
+		if bm.PageFrom < 1 || bm.PageFrom >= len(position) || position[bm.PageFrom] == 0 {
+			result = append(result, kids...)
+			continue
+		}
+
+		bm.PageFrom = position[bm.PageFrom]
+		bm.PageThru = 0
+		bm.Parent = nil
+		bm.Kids = kids
+		result = append(result, bm)
+	}
+	return result
+}
+
+// shiftBookmarks moves bookmarks and their children down by offset pages
+func shiftBookmarks(bms []pdfcpu.Bookmark, offset int) {
+	for i := range bms {
+		bms[i].PageFrom += offset
+		if bms[i].PageThru > 0 {
+			bms[i].PageThru += offset
+		}
+		bms[i].Parent = nil
+		shiftBookmarks(bms[i].Kids, offset)
+	}
+}
+
+// interleaveOrder returns the page order that alternates a document of a pages with the
+// b pages appended after it. Pages left over from the longer document come last.
+func interleaveOrder(a, b int, reverseSecond bool) []int {
+	order := make([]int, 0, a+b)
+	for i := 0; i < max(a, b); i++ {
+		if i < a {
+			order = append(order, i+1)
+		}
+		if i < b {
+			if reverseSecond {
+				order = append(order, a+b-i)
+			} else {
+				order = append(order, a+1+i)
+			}
+		}
+	}
+	return order
+}
+
+// moveBookmarks points bookmarks and their children at new page positions.
+// Outlines must be in page order, so siblings are re-sorted and a parent
+// moves up to its first child if that now comes earlier.
+func moveBookmarks(bms []pdfcpu.Bookmark, position []int) {
+	for i := range bms {
+		bms[i].PageFrom = position[bms[i].PageFrom]
+		bms[i].PageThru = 0
+		moveBookmarks(bms[i].Kids, position)
+	}
+	orderBookmarks(bms)
+}
+
+// orderBookmarks sorts sibling bookmarks by page after moving each parent up
+// to its first child if that comes earlier. Their children must be in order.
+func orderBookmarks(bms []pdfcpu.Bookmark) {
+	for i := range bms {
+		if len(bms[i].Kids) > 0 {
+			bms[i].PageFrom = min(bms[i].PageFrom, bms[i].Kids[0].PageFrom)
+		}
+	}
+	sort.SliceStable(bms, func(i, j int) bool { return bms[i].PageFrom < bms[j].PageFrom })
+}
+
+// replaceOutline replaces the bookmarks of a PDF file in place; an empty outline removes them
+func replaceOutline(path string, bms []pdfcpu.Bookmark) error {
+	ctx, err := api.ReadContextFile(path)
+	if err != nil {
+		return err
+	}
+
+	if len(bms) == 0 {
+		if _, err := pdfcpu.RemoveBookmarks(ctx); err != nil {
+			return err
+		}
+	} else if err := pdfcpu.AddBookmarks(ctx, bms, true); err != nil {
+		return err
+	}
+
+	return api.WriteContextFile(ctx, path)
+}
+
+// addTableOfContents inserts a page in front of a PDF file listing each entry and its page.
+// Page numbers refer to the document after the contents page has been added.
+func addTableOfContents(path string, entries []pdfcpu.Bookmark) error {
+	ctx, err := api.ReadContextFile(path)
+	if err != nil {
+		return err
+	}
+
+	// A blank page inserted before page 1 takes the size of page 1
+	if err := ctx.InsertBlankPages(types.IntSet{1: true}, nil, true); err != nil {
+		return err
+	}
+	if err := api.WriteContextFile(ctx, path); err != nil {
+		return err
+	}
+
+	// pdfcpu drops empty lines, so spacer lines hold a single space
+	titles := []string{"Contents", " "}
+	pages := []string{" ", " "}
+	for _, entry := range entries {
+		title := entry.Title
+		if runes := []rune(title); len(runes) > 60 {
+			title = string(runes[:57]) + "..."
+		}
+		titles = append(titles, title)
+		pages = append(pages, strconv.Itoa(entry.PageFrom+1))
+	}
+
+	// Titles and page numbers are separate columns with the same line spacing
+	var wms []*model.Watermark
+	for _, column := range []struct {
+		lines  []string
+		anchor string
+		align  string
+		dx     int
+	}{
+		{titles, "tl", "l", 2 * stampMargin},
+		{pages, "tr", "r", -2 * stampMargin},
+	} {
+		desc := strings.Join([]string{
+			"position:" + column.anchor,
+			fmt.Sprintf("offset:%d %d", column.dx, -2*stampMargin),
+			"aligntext:" + column.align,
+			"fontname:Helvetica",
+			fmt.Sprintf("points:%d", tocFontSize),
+			"fillcolor:#000000",
+			"scalefactor:1 abs",
+			"rotation:0",
+			"opacity:1",
+		}, ", ")
+
+		wm, err := api.TextWatermark(strings.Join(column.lines, "\n"), desc, true, false, types.POINTS)
+		if err != nil {
+			return err
+		}
+		wms = append(wms, wm)
+	}
+
+	tmpPath := path + ".toc"
+	defer os.Remove(tmpPath)
+
+	if err := api.AddWatermarksSliceMapFile(path, tmpPath, map[int][]*model.Watermark{1: wms}, nil); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,77 @@
+package pdf
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeOutlinePDF writes a one-page PDF with a single bookmark and returns its path
+func writeOutlinePDF(t *testing.T, title string) string {
+	t.Helper()
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R /Outlines 4 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		"<< /Type /Outlines /First 5 0 R /Last 5 0 R /Count 1 >>",
+		fmt.Sprintf("<< /Title (%s) /Parent 4 0 R /Dest [3 0 R /Fit] >>", title),
+	}
+	return writeTestPDF(t, objects, "")
+}
+
+func TestMergePDFsBookmarks(t *testing.T) {
+	a, b := writeOutlinePDF(t, "Intro"), writeOutlinePDF(t, "Summary")
+
+	tests := []struct {
+		name    string
+		sources []MergeSource
+		opts    MergeOptions
+		want    []Bookmark
+	}{
+		{
+			"kept by default",
+			[]MergeSource{{Path: a}, {Path: b}},
+			MergeOptions{},
+			[]Bookmark{{Title: "Intro", Page: 1, Level: 1}, {Title: "Summary", Page: 2, Level: 1}},
+		},
+		{
+			"dropped for one file",
+			[]MergeSource{{Path: a, DropBookmarks: true}, {Path: b}},
+			MergeOptions{},
+			[]Bookmark{{Title: "Summary", Page: 2, Level: 1}},
+		},
+		{
+			"under file bookmarks",
+			[]MergeSource{{Path: a, Name: "a.pdf"}, {Path: b, Name: "b.pdf", DropBookmarks: true}},
+			MergeOptions{FileBookmarks: true},
+			[]Bookmark{
+				{Title: "a", Page: 1, Level: 1, Children: []Bookmark{{Title: "Intro", Page: 1, Level: 2}}},
+				{Title: "b", Page: 2, Level: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		out := filepath.Join(t.TempDir(), "merged.pdf")
+		if err := MergePDFs(tt.sources, out, tt.opts); err != nil {
+			t.Errorf("%s: MergePDFs error: %v", tt.name, err)
+			continue
+		}
+		got, err := ExportBookmarks(out)
+		if err != nil {
+			t.Errorf("%s: ExportBookmarks error: %v", tt.name, err)
+			continue
+		}
+		if !slices.EqualFunc(got, tt.want, equalBookmark) {
+			t.Errorf("%s: bookmarks = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// equalBookmark reports whether two bookmarks and their children are the same
+func equalBookmark(a, b Bookmark) bool {
+	return a.Title == b.Title && a.Page == b.Page && a.Level == b.Level &&
+		slices.EqualFunc(a.Children, b.Children, equalBookmark)
+}
@@ -0,0 +1,389 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+	"unicode"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Metadata is the document information of a PDF file
+type Metadata struct {
+	Title        string            `json:"title"`
+	Author       string            `json:"author"`
+	Subject      string            `json:"subject"`
+	Keywords     string            `json:"keywords"`
+	Creator      string            `json:"creator"`
+	Producer     string            `json:"producer"`
+	CreationDate *time.Time        `json:"creationDate,omitempty"`
+	ModDate      *time.Time        `json:"modDate,omitempty"`
+	Custom       map[string]string `json:"custom,omitempty"` // Other text entries of the information dictionary
+	HasXMP       bool              `json:"hasXMP"`           // The document carries an XMP metadata stream
+}
+
+// metadataTextKeys maps the information dictionary's text entries to Metadata fields
+var metadataTextKeys = []struct {
+	key   string
+	field func(*Metadata) *string
+}{
+	{"Title", func(md *Metadata) *string { return &md.Title }},
+	{"Author", func(md *Metadata) *string { return &md.Author }},
+	{"Subject", func(md *Metadata) *string { return &md.Subject }},
+	{"Keywords", func(md *Metadata) *string { return &md.Keywords }},
+	{"Creator", func(md *Metadata) *string { return &md.Creator }},
+	{"Producer", func(md *Metadata) *string { return &md.Producer }},
+}
+
+// ReadMetadata returns the document information of a PDF file
+func ReadMetadata(inputPath string) (Metadata, error) {
+	ctx, err := api.ReadContextFile(inputPath)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	md := Metadata{HasXMP: ctx.RootDict["Metadata"] != nil}
+	if ctx.Info == nil {
+		return md, nil
+	}
+
+	d, err := ctx.DereferenceDict(*ctx.Info)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("failed to read document information: %w", err)
+	}
+
+	known := map[string]*string{}
+	for _, k := range metadataTextKeys {
+		known[k.key] = k.field(&md)
+	}
+
+	for key, value := range d {
+		o, err := ctx.Dereference(value)
+		if err != nil || o == nil {
+			continue
+		}
+		s, err := types.StringOrHexLiteral(o)
+		if err != nil {
+			// Entries like Trapped are names, not text
+			continue
+		}
+
+		switch key {
+		case "CreationDate", "ModDate":
+			t, ok := types.DateTime(*s, true)
+			if !ok {
+				continue
+			}
+			if key == "CreationDate" {
+				md.CreationDate = &t
+			} else {
+				md.ModDate = &t
+			}
+		default:
+			if field, ok := known[key]; ok {
+				*field = *s
+				continue
+			}
+			if md.Custom == nil {
+				md.Custom = map[string]string{}
+			}
+			md.Custom[key] = *s
+		}
+	}
+
+	return md, nil
+}
+
+// WriteMetadata writes a copy of a PDF file whose document information is exactly md.
+// Empty fields are left out. The document's XMP stream is removed because viewers
+// prefer it over the information dictionary and it would still hold the old values.
+func WriteMetadata(inputPath, outputPath string, md Metadata) error {
+	ctx, err := readForMetadata(inputPath)
+	if err != nil {
+		return err
+	}
+
+	delete(ctx.RootDict, "Metadata")
+
+	if err := writeWithInfo(ctx, outputPath, md); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// StripMetadata writes a copy of a PDF file without document information
+// and without XMP metadata on the document, its pages or any other object
+func StripMetadata(inputPath, outputPath string) error {
+	ctx, err := readForMetadata(inputPath)
+	if err != nil {
+		return err
+	}
+
+	delete(ctx.RootDict, "Metadata")
+	for _, entry := range ctx.Table {
+		if entry == nil {
+			continue
+		}
+		switch o := entry.Object.(type) {
+		case types.Dict:
+			delete(o, "Metadata")
+		case types.StreamDict:
+			delete(o.Dict, "Metadata")
+		}
+	}
+
+	if err := writeWithInfo(ctx, outputPath, Metadata{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// readForMetadata reads a PDF file that is about to get new document information
+func readForMetadata(inputPath string) (*model.Context, error) {
+	ctx, err := api.ReadContextFile(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	// Strings in the new information dictionary would have to be encrypted too
+	if ctx.Encrypt != nil {
+		return nil, fmt.Errorf("PDF is encrypted, remove its password first")
+	}
+
+	return ctx, nil
+}
+
+// writeWithInfo writes ctx with md as its document information. pdfcpu always
+// stamps its own Producer and dates when writing, so the document is written
+// with an empty information dictionary that is then swapped for the final one.
+func writeWithInfo(ctx *model.Context, outputPath string, md Metadata) error {
+	info, err := infoDict(md)
+	if err != nil {
+		return err
+	}
+
+	// The swap moves the objects after the dictionary, which is only
+	// possible to track in a classic cross-reference table
+	ctx.WriteObjectStream = false
+	ctx.WriteXRefStream = false
+
+	// pdfcpu only writes objects it can reach, so the old dictionary and
+	// anything it refers to are left out of the file
+	ref, err := ctx.IndRefForNewObject(types.NewDict())
+	if err != nil {
+		return fmt.Errorf("failed to write document information: %w", err)
+	}
+	ctx.Info = ref
+
+	var buf bytes.Buffer
+	if err := api.WriteContext(ctx, &buf); err != nil {
+		return fmt.Errorf("failed to write PDF: %w", err)
+	}
+
+	data, err := replaceObject(buf.Bytes(), ref.ObjectNumber.Value(), info.PDFString())
+	if err != nil {
+		return fmt.Errorf("failed to write document information: %w", err)
+	}
+
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write PDF: %w", err)
+	}
+
+	return nil
+}
+
+// infoDict builds an information dictionary holding the non-empty fields of md
+func infoDict(md Metadata) (types.Dict, error) {
+	d := types.NewDict()
+
+	text := map[string]string{}
+	for key, value := range md.Custom {
+		text[key] = value
+	}
+	for _, k := range metadataTextKeys {
+		text[k.key] = *k.field(&md)
+	}
+
+	for key, value := range text {
+		if value == "" {
+			continue
+		}
+		if !validInfoKey(key) {
+			return nil, fmt.Errorf("invalid metadata name %q", key)
+		}
+		s, err := encodeInfoString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+		d[key] = types.StringLiteral(s)
+	}
+
+	if md.CreationDate != nil {
+		d["CreationDate"] = types.StringLiteral(types.DateString(*md.CreationDate))
+	}
+	if md.ModDate != nil {
+		d["ModDate"] = types.StringLiteral(types.DateString(*md.ModDate))
+	}
+
+	return d, nil
+}
+
+// validInfoKey reports whether key can be written as a PDF name without escaping
+func validInfoKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeInfoString escapes a text string, using UTF-16 if it is not plain ASCII
+func encodeInfoString(s string) (string, error) {
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			escaped, err := types.EscapedUTF16String(s)
+			if err != nil {
+				return "", err
+			}
+			return *escaped, nil
+		}
+	}
+
+	escaped, err := types.Escape(s)
+	if err != nil {
+		return "", err
+	}
+	return *escaped, nil
+}
+
+// replaceObject returns a copy of a PDF file with the body of object objNr
+// replaced, moving the objects after it in its cross-reference table. The file
+// must end in a single cross-reference table and the old body must not contain
+// the keyword endobj.
+func replaceObject(data []byte, objNr int, body string) ([]byte, error) {
+	xref, err := lastXRefOffset(data)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data[xref:], []byte("xref")) {
+		return nil, fmt.Errorf("missing cross-reference table")
+	}
+	trailer := bytes.Index(data[xref:], []byte("trailer"))
+	if trailer < 0 {
+		return nil, fmt.Errorf("missing trailer")
+	}
+	trailer += xref
+
+	// Subsections are a first object number and a count, followed by
+	// an offset, a generation number and n or f for each object
+	type xrefEntry struct {
+		offset, gen int
+		inUse       bool
+	}
+	type xrefSection struct {
+		start   int
+		entries []xrefEntry
+	}
+	var sections []xrefSection
+	fields := bytes.Fields(data[xref+len("xref") : trailer])
+	atoi := func(i int) int {
+		if i >= len(fields) {
+			return -1
+		}
+		n, err := strconv.Atoi(string(fields[i]))
+		if err != nil {
+			return -1
+		}
+		return n
+	}
+	objStart, gen := -1, 0
+	for i := 0; i < len(fields); {
+		start, count := atoi(i), atoi(i+1)
+		if start < 0 || count < 0 || i+2+3*count > len(fields) {
+			return nil, fmt.Errorf("invalid cross-reference table")
+		}
+		i += 2
+
+		section := xrefSection{start: start}
+		for j := 0; j < count; j, i = j+1, i+3 {
+			entry := xrefEntry{offset: atoi(i), gen: atoi(i + 1), inUse: string(fields[i+2]) == "n"}
+			if entry.offset < 0 || entry.gen < 0 {
+				return nil, fmt.Errorf("invalid cross-reference table")
+			}
+			if entry.inUse && start+j == objNr {
+				objStart, gen = entry.offset, entry.gen
+			}
+			section.entries = append(section.entries, entry)
+		}
+		sections = append(sections, section)
+	}
+	if objStart < 0 || objStart >= xref {
+		return nil, fmt.Errorf("object %d not found", objNr)
+	}
+
+	end := bytes.Index(data[objStart:xref], []byte("endobj"))
+	if end < 0 {
+		return nil, fmt.Errorf("object %d is not terminated", objNr)
+	}
+	end += objStart + len("endobj")
+
+	object := fmt.Sprintf("%d %d obj\n%s\nendobj", objNr, gen, body)
+	delta := len(object) - (end - objStart)
+
+	var out bytes.Buffer
+	out.Write(data[:objStart])
+	out.WriteString(object)
+	out.Write(data[end:xref])
+
+	out.WriteString("xref\n")
+	for _, section := range sections {
+		fmt.Fprintf(&out, "%d %d\n", section.start, len(section.entries))
+		for _, entry := range section.entries {
+			if !entry.inUse {
+				fmt.Fprintf(&out, "%010d %05d f \n", entry.offset, entry.gen)
+				continue
+			}
+			offset := entry.offset
+			if offset > objStart {
+				offset += delta
+			}
+			fmt.Fprintf(&out, "%010d %05d n \n", offset, entry.gen)
+		}
+	}
+
+	startxref := bytes.LastIndex(data, []byte("startxref"))
+	out.Write(data[trailer:startxref])
+	fmt.Fprintf(&out, "startxref\n%d\n%%%%EOF\n", xref+delta)
+
+	return out.Bytes(), nil
+}
+
+// lastXRefOffset returns the offset of the last cross-reference section of a PDF file
+func lastXRefOffset(data []byte) (int, error) {
+	i := bytes.LastIndex(data, []byte("startxref"))
+	if i < 0 {
+		return 0, fmt.Errorf("missing startxref")
+	}
+
+	fields := bytes.Fields(data[i+len("startxref"):])
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("missing startxref offset")
+	}
+
+	offset, err := strconv.Atoi(string(fields[0]))
+	if err != nil || offset < 0 || offset >= len(data) {
+		return 0, fmt.Errorf("invalid startxref offset %q", fields[0])
+	}
+	return offset, nil
+}
@@ -0,0 +1,93 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeMetadataPDF writes a one-page PDF whose information dictionary and XMP
+// stream both name SecretAuthor, and returns its path
+func writeMetadataPDF(t *testing.T) string {
+	t.Helper()
+
+	xmp := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><dc:creator>SecretAuthor</dc:creator></x:xmpmeta>`
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R /Metadata 5 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		"<< /Title (SecretTitle) /Author (SecretAuthor) /Producer (SecretProducer) /CreationDate (D:20010203040506Z) >>",
+		fmt.Sprintf("<< /Type /Metadata /Subtype /XML /Length %d >>\nstream\n%s\nendstream", len(xmp), xmp),
+	}
+	return writeTestPDF(t, objects, "/Info 4 0 R ")
+}
+
+// checkRewritten fails unless the PDF at path is a single full revision without any of the old values
+func checkRewritten(t *testing.T, name, path string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, old := range []string{"SecretAuthor", "SecretTitle", "SecretProducer", "D:20010203040506"} {
+		if bytes.Contains(data, []byte(old)) {
+			t.Errorf("%s: output still contains %q", name, old)
+		}
+	}
+	if n := bytes.Count(data, []byte("startxref")); n != 1 {
+		t.Errorf("%s: output has %d revisions, want 1", name, n)
+	}
+	return data
+}
+
+func TestStripMetadata(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.pdf")
+	if err := StripMetadata(writeMetadataPDF(t), out); err != nil {
+		t.Fatalf("StripMetadata error: %v", err)
+	}
+	checkRewritten(t, "StripMetadata", out)
+
+	md, err := ReadMetadata(out)
+	if err != nil {
+		t.Fatalf("ReadMetadata error: %v", err)
+	}
+	if md.Title != "" || md.Author != "" || md.Producer != "" || md.CreationDate != nil || md.ModDate != nil || md.HasXMP {
+		t.Errorf("ReadMetadata = %+v, want no metadata", md)
+	}
+}
+
+func TestWriteMetadata(t *testing.T) {
+	created := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	want := Metadata{
+		Title:        "New title",
+		Author:       "Zoë",
+		Producer:     "lovepdf",
+		CreationDate: &created,
+		Custom:       map[string]string{"Department": "Legal (EU)"},
+	}
+
+	out := filepath.Join(t.TempDir(), "out.pdf")
+	if err := WriteMetadata(writeMetadataPDF(t), out, want); err != nil {
+		t.Fatalf("WriteMetadata error: %v", err)
+	}
+	checkRewritten(t, "WriteMetadata", out)
+
+	md, err := ReadMetadata(out)
+	if err != nil {
+		t.Fatalf("ReadMetadata error: %v", err)
+	}
+	if md.Title != want.Title || md.Author != want.Author || md.Producer != want.Producer ||
+		md.Custom["Department"] != "Legal (EU)" || md.HasXMP {
+		t.Errorf("ReadMetadata = %+v, want %+v", md, want)
+	}
+	if md.CreationDate == nil || !md.CreationDate.Equal(created) {
+		t.Errorf("CreationDate = %v, want %v", md.CreationDate, created)
+	}
+	if md.ModDate != nil {
+		t.Errorf("ModDate = %v, want none", md.ModDate)
+	}
+}
@@ -0,0 +1,59 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// PageCount returns the number of pages in a PDF file
+func PageCount(inputPath string) (int, error) {
+	count, err := api.PageCountFile(inputPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read page count: %w", err)
+	}
+	return count, nil
+}
+
+// ReorderPages writes the pages of a PDF file in a new order.
+// order lists 1-based page numbers; pages may be repeated or left out.
+func ReorderPages(inputPath, outputPath string, order []int) error {
+	if len(order) == 0 {
+		return fmt.Errorf("page order is empty")
+	}
+
+	f, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer f.Close()
+
+	conf := model.NewDefaultConfiguration()
+	conf.Cmd = model.COLLECT
+
+	ctx, err := api.ReadValidateAndOptimize(f, conf)
+	if err != nil {
+		return fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	for _, page := range order {
+		if page < 1 || page > ctx.PageCount {
+			return fmt.Errorf("page %d is out of range (document has %d pages)", page, ctx.PageCount)
+		}
+	}
+
+	// ExtractPages copies pages in the given order, including duplicates
+	ctxDest, err := pdfcpu.ExtractPages(ctx, order, false)
+	if err != nil {
+		return fmt.Errorf("failed to reorder pages: %w", err)
+	}
+
+	if err := api.WriteContextFile(ctxDest, outputPath); err != nil {
+		return fmt.Errorf("failed to write PDF: %w", err)
+	}
+
+	return nil
+}
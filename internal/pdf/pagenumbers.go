@@ -0,0 +1,177 @@
+package pdf
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// PageNumberOptions describes page numbers and optional header/footer text.
+// Format, Header and Footer support the placeholders {n}, {total}, {date} and {filename}.
+type PageNumberOptions struct {
+	Format   string // Page number template, e.g. "Page {n} of {total}"
+	Position string // Anchor: tl, tc, tr, bl, bc, br
+	Start    int    // Number printed on the first stamped page
+	Digits   int    // Zero-pad {n} to this many digits; 0 for no padding
+	Pages    string // Pages to stamp like "1-10"; empty for all pages
+	Skip     string // Pages to leave unstamped like "1" for a cover page
+	FontName string // "Helvetica", "Times-Roman" or "Courier"
+	FontSize int    // Font size in points
+	Color    string // Text color as "#rrggbb"
+	Header   string // Optional text printed top center
+	Footer   string // Optional text printed bottom center
+	Filename string // Value substituted for {filename}
+}
+
+// stampMargin is the distance in points between stamped text and the page edge
+const stampMargin = 24
+
+var pageNumberPositions = []string{"tl", "tc", "tr", "bl", "bc", "br"}
+
+// AddPageNumbers stamps page numbers and optional headers and footers onto a PDF file
+func AddPageNumbers(inputPath, outputPath string, opts PageNumberOptions) error {
+	if err := validatePageNumberOptions(opts); err != nil {
+		return fmt.Errorf("invalid page number options: %w", err)
+	}
+
+	pageCount, err := api.PageCountFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	pages, err := stampedPages(opts.Pages, opts.Skip, pageCount)
+	if err != nil {
+		return err
+	}
+	if len(pages) == 0 {
+		return fmt.Errorf("no pages selected for numbering")
+	}
+
+	total := opts.Start + len(pages) - 1
+	date := time.Now().Format("2006-01-02")
+
+	m := map[int][]*model.Watermark{}
+	for i, page := range pages {
+		replacer := strings.NewReplacer(
+			"{n}", fmt.Sprintf("%0*d", opts.Digits, opts.Start+i),
+			"{total}", strconv.Itoa(total),
+			"{date}", date,
+			"{filename}", opts.Filename,
+		)
+
+		// Texts sharing an anchor are joined so they do not overlap
+		texts := map[string][]string{}
+		var anchors []string
+		add := func(anchor, text string) {
+			if text == "" {
+				return
+			}
+			if _, ok := texts[anchor]; !ok {
+				anchors = append(anchors, anchor)
+			}
+			texts[anchor] = append(texts[anchor], replacer.Replace(text))
+		}
+		add("tc", opts.Header)
+		add(opts.Position, opts.Format)
+		add("bc", opts.Footer)
+
+		for _, anchor := range anchors {
+			wm, err := api.TextWatermark(strings.Join(texts[anchor], "  |  "), stampDescription(anchor, opts), true, false, types.POINTS)
+			if err != nil {
+				return fmt.Errorf("failed to prepare page numbers: %w", err)
+			}
+			m[page] = append(m[page], wm)
+		}
+	}
+
+	if err := api.AddWatermarksSliceMapFile(inputPath, outputPath, m, nil); err != nil {
+		return fmt.Errorf("failed to add page numbers: %w", err)
+	}
+
+	return nil
+}
+
+// validatePageNumberOptions checks the values that end up in pdfcpu's description string
+func validatePageNumberOptions(opts PageNumberOptions) error {
+	if strings.TrimSpace(opts.Format) == "" && opts.Header == "" && opts.Footer == "" {
+		return fmt.Errorf("nothing to stamp")
+	}
+	if !slices.Contains(pageNumberPositions, opts.Position) {
+		return fmt.Errorf("unknown position: %s", opts.Position)
+	}
+	if opts.Start < 0 {
+		return fmt.Errorf("start number must not be negative")
+	}
+	if opts.Digits < 0 || opts.Digits > 12 {
+		return fmt.Errorf("digits must be between 0 and 12")
+	}
+	if !slices.Contains(watermarkFonts, opts.FontName) {
+		return fmt.Errorf("unsupported font: %s", opts.FontName)
+	}
+	if opts.FontSize < 1 || opts.FontSize > 200 {
+		return fmt.Errorf("font size must be between 1 and 200")
+	}
+	if !hexColor.MatchString(opts.Color) {
+		return fmt.Errorf("color must be in #rrggbb format")
+	}
+	return nil
+}
+
+// stampedPages resolves the pages to stamp, leaving out the pages to skip
+func stampedPages(pageRange, skip string, pageCount int) ([]int, error) {
+	pages, err := selectPages(pageRange, pageCount)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page range: %w", err)
+	}
+
+	if strings.TrimSpace(skip) == "" {
+		return pages, nil
+	}
+
+	skipped, err := selectPages(skip, pageCount)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pages to skip: %w", err)
+	}
+
+	var result []int
+	for _, page := range pages {
+		if !slices.Contains(skipped, page) {
+			result = append(result, page)
+		}
+	}
+	return result, nil
+}
+
+// stampDescription builds pdfcpu's description string for text anchored near a page edge
+func stampDescription(anchor string, opts PageNumberOptions) string {
+	dx, dy := 0, 0
+	switch anchor[0] {
+	case 't':
+		dy = -stampMargin
+	case 'b':
+		dy = stampMargin
+	}
+	switch anchor[len(anchor)-1] {
+	case 'l':
+		dx = stampMargin
+	case 'r':
+		dx = -stampMargin
+	}
+
+	return strings.Join([]string{
+		"position:" + anchor,
+		fmt.Sprintf("offset:%d %d", dx, dy),
+		"fontname:" + opts.FontName,
+		fmt.Sprintf("points:%d", opts.FontSize),
+		"fillcolor:" + opts.Color,
+		"scalefactor:1 abs",
+		"rotation:0",
+		"opacity:1",
+	}, ", ")
+}
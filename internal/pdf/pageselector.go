@@ -0,0 +1,301 @@
+package pdf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PageSelector is a parsed page selection shared by all page-based tools.
+//
+// A selection is a comma-separated list of tokens:
+//
+//	5        a single page
+//	1-3      a range of pages
+//	5- / -3  open ranges, from page 5 to the end or from the start to page 3
+//	last     the last page; "end" is an alias
+//	last-2   the page two before the last one, usable in ranges like "3-last-1"
+//	odd      odd pages; "even" selects even pages
+//	!7       excludes page 7; any token can be negated, e.g. "!odd" or "!1-3"
+//
+// Exclusions always win over inclusions regardless of their position. If a
+// selection only contains exclusions they are removed from all pages, so "!1"
+// selects everything but the first page.
+type PageSelector struct {
+	expr  string
+	terms []pageTerm
+}
+
+// pageTerm is one token of a selection
+type pageTerm struct {
+	token   string
+	exclude bool
+	parity  int // 1 for odd, 2 for even, 0 for a range
+	start   pageRef
+	end     pageRef
+}
+
+// pageRef is a page number, or an offset from the last page when fromLast is set
+type pageRef struct {
+	fromLast bool
+	n        int
+}
+
+// ParsePageSelector parses a page selection like "1-3,5-,last-2,!7".
+// It only checks the syntax; use Pages to validate it against a document.
+func ParsePageSelector(expr string) (PageSelector, error) {
+	s := PageSelector{expr: strings.TrimSpace(expr)}
+	if s.expr == "" {
+		return s, nil
+	}
+
+	for _, token := range strings.Split(s.expr, ",") {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+
+		term, err := parsePageTerm(token)
+		if err != nil {
+			return PageSelector{}, fmt.Errorf("invalid page token %q: %w", token, err)
+		}
+		s.terms = append(s.terms, term)
+	}
+
+	if len(s.terms) == 0 {
+		return PageSelector{}, fmt.Errorf("invalid page selection %q: no pages specified", expr)
+	}
+
+	return s, nil
+}
+
+// IsEmpty reports whether the selection has no tokens and therefore selects every page
+func (s PageSelector) IsEmpty() bool {
+	return len(s.terms) == 0
+}
+
+// String returns the selection as it was given
+func (s PageSelector) String() string {
+	return s.expr
+}
+
+// Pages resolves the selection against a document with pageCount pages.
+// It returns the selected page numbers in ascending order without duplicates.
+// An empty selection returns every page.
+func (s PageSelector) Pages(pageCount int) ([]int, error) {
+	if pageCount < 1 {
+		return nil, fmt.Errorf("document has no pages")
+	}
+
+	selected := make([]bool, pageCount+1)
+	included := false
+	for _, term := range s.terms {
+		if !term.exclude {
+			included = true
+		}
+	}
+	if !included {
+		for i := 1; i <= pageCount; i++ {
+			selected[i] = true
+		}
+	}
+
+	// Inclusions are applied before exclusions so the order of tokens does not matter
+	for _, exclude := range []bool{false, true} {
+		for _, term := range s.terms {
+			if term.exclude != exclude {
+				continue
+			}
+			first, last, step, err := term.bounds(pageCount)
+			if err != nil {
+				return nil, fmt.Errorf("invalid page token %q: %w", term.token, err)
+			}
+			for i := first; i <= last; i += step {
+				selected[i] = !exclude
+			}
+		}
+	}
+
+	var pages []int
+	for i := 1; i <= pageCount; i++ {
+		if selected[i] {
+			pages = append(pages, i)
+		}
+	}
+
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("page selection %q selects no pages", s.expr)
+	}
+
+	return pages, nil
+}
+
+// bounds resolves a term to the pages first, first+step, ... up to last
+func (t pageTerm) bounds(pageCount int) (first, last, step int, err error) {
+	switch t.parity {
+	case 1:
+		return 1, pageCount, 2, nil
+	case 2:
+		return 2, pageCount, 2, nil
+	}
+
+	if first, err = t.start.resolve(pageCount); err != nil {
+		return 0, 0, 0, err
+	}
+	if last, err = t.end.resolve(pageCount); err != nil {
+		return 0, 0, 0, err
+	}
+	if first > last {
+		return 0, 0, 0, fmt.Errorf("start page %d is after end page %d", first, last)
+	}
+	return first, last, 1, nil
+}
+
+// resolve turns a reference into a page number and checks it exists
+func (r pageRef) resolve(pageCount int) (int, error) {
+	page := r.n
+	if r.fromLast {
+		page = pageCount - r.n
+	}
+	if page < 1 || page > pageCount {
+		return 0, fmt.Errorf("page %d is out of range (document has %d pages)", page, pageCount)
+	}
+	return page, nil
+}
+
+// parsePageTerm parses a single token without surrounding whitespace
+func parsePageTerm(token string) (pageTerm, error) {
+	term := pageTerm{token: token}
+
+	body := token
+	if strings.HasPrefix(body, "!") {
+		term.exclude = true
+		body = strings.TrimSpace(body[1:])
+	}
+	if body == "" {
+		return term, fmt.Errorf("missing pages")
+	}
+
+	switch strings.ToLower(body) {
+	case "odd":
+		term.parity = 1
+		return term, nil
+	case "even":
+		term.parity = 2
+		return term, nil
+	}
+
+	// An open start is written as a leading dash, e.g. "-3"
+	openStart := strings.HasPrefix(body, "-")
+	if openStart {
+		term.start = pageRef{n: 1}
+	} else {
+		ref, rest, err := parsePageRef(body)
+		if err != nil {
+			return term, err
+		}
+		term.start = ref
+		if rest == "" {
+			term.end = ref
+			return term, nil
+		}
+		body = rest
+	}
+
+	// body now starts with the range dash
+	body = strings.TrimSpace(body[1:])
+	if body == "" {
+		if openStart {
+			return term, fmt.Errorf("range has neither start nor end")
+		}
+		term.end = pageRef{fromLast: true}
+		return term, nil
+	}
+
+	ref, rest, err := parsePageRef(body)
+	if err != nil {
+		return term, err
+	}
+	if rest != "" {
+		return term, fmt.Errorf("unexpected %q", rest)
+	}
+	term.end = ref
+	return term, nil
+}
+
+// parsePageRef parses a page number, "last", "end" or "last-N" at the start of s.
+// It returns the remaining input, which is empty or starts with a range dash.
+func parsePageRef(s string) (pageRef, string, error) {
+	s = strings.TrimSpace(s)
+
+	lower := strings.ToLower(s)
+	for _, keyword := range []string{"last", "end"} {
+		if !strings.HasPrefix(lower, keyword) {
+			continue
+		}
+		rest := strings.TrimSpace(s[len(keyword):])
+
+		// "last-2" is an offset; a dash followed by anything else starts a range
+		if strings.HasPrefix(rest, "-") {
+			digits, after := leadingDigits(strings.TrimSpace(rest[1:]))
+			after = strings.TrimSpace(after)
+			if digits != "" && (after == "" || strings.HasPrefix(after, "-")) {
+				n, err := strconv.Atoi(digits)
+				if err != nil {
+					return pageRef{}, "", fmt.Errorf("offset %q is too large", digits)
+				}
+				return pageRef{fromLast: true, n: n}, after, nil
+			}
+		}
+
+		if rest != "" && !strings.HasPrefix(rest, "-") {
+			return pageRef{}, "", fmt.Errorf("unexpected %q after %q", rest, keyword)
+		}
+		return pageRef{fromLast: true}, rest, nil
+	}
+
+	digits, rest := leadingDigits(s)
+	if digits == "" {
+		return pageRef{}, "", fmt.Errorf("expected a page number, \"last\", \"odd\" or \"even\"")
+	}
+	rest = strings.TrimSpace(rest)
+	if rest != "" && !strings.HasPrefix(rest, "-") {
+		return pageRef{}, "", fmt.Errorf("unexpected %q after %s", rest, digits)
+	}
+
+	n, err := strconv.Atoi(digits)
+	if err != nil {
+		return pageRef{}, "", fmt.Errorf("page number %s is too large", digits)
+	}
+	if n < 1 {
+		return pageRef{}, "", fmt.Errorf("page numbers start at 1")
+	}
+	return pageRef{n: n}, rest, nil
+}
+
+// leadingDigits splits s into its leading ASCII digits and the rest
+func leadingDigits(s string) (string, string) {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// selectPages parses expr and resolves it against a document with pageCount pages
+func selectPages(expr string, pageCount int) ([]int, error) {
+	sel, err := ParsePageSelector(expr)
+	if err != nil {
+		return nil, err
+	}
+	return sel.Pages(pageCount)
+}
+
+// pageStrings formats page numbers for pdfcpu's page selection arguments
+func pageStrings(pages []int) []string {
+	result := make([]string, len(pages))
+	for i, page := range pages {
+		result[i] = strconv.Itoa(page)
+	}
+	return result
+}
@@ -0,0 +1,128 @@
+package pdf
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPageSelectorPages(t *testing.T) {
+	tests := []struct {
+		expr      string
+		pageCount int
+		want      []int
+	}{
+		{"", 3, []int{1, 2, 3}},
+		{"2", 5, []int{2}},
+		{"1-3,5", 6, []int{1, 2, 3, 5}},
+		{" 3 - 4 , 1 ", 5, []int{1, 3, 4}},
+		{"3,1,3", 5, []int{1, 3}},
+		{"5-", 7, []int{5, 6, 7}},
+		{"-3", 7, []int{1, 2, 3}},
+		{"last", 4, []int{4}},
+		{"end", 4, []int{4}},
+		{"LAST", 4, []int{4}},
+		{"last-2", 10, []int{8}},
+		{"last-2-", 10, []int{8, 9, 10}},
+		{"3-last-6", 10, []int{3, 4}},
+		{"last-3-last-1", 10, []int{7, 8, 9}},
+		{"-last", 3, []int{1, 2, 3}},
+		{"odd", 5, []int{1, 3, 5}},
+		{"even", 5, []int{2, 4}},
+		{"1-20,!7", 20, []int{1, 2, 3, 4, 5, 6, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}},
+		{"!1", 4, []int{2, 3, 4}},
+		{"!odd", 5, []int{2, 4}},
+		{"1-6,!2-4", 6, []int{1, 5, 6}},
+		{"odd,!last", 5, []int{1, 3}},
+		{"!7,1-10", 10, []int{1, 2, 3, 4, 5, 6, 8, 9, 10}},
+	}
+
+	for _, tt := range tests {
+		sel, err := ParsePageSelector(tt.expr)
+		if err != nil {
+			t.Errorf("ParsePageSelector(%q) error: %v", tt.expr, err)
+			continue
+		}
+		got, err := sel.Pages(tt.pageCount)
+		if err != nil {
+			t.Errorf("%q.Pages(%d) error: %v", tt.expr, tt.pageCount, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%q.Pages(%d) = %v, want %v", tt.expr, tt.pageCount, got, tt.want)
+		}
+	}
+}
+
+func TestPageSelectorErrors(t *testing.T) {
+	tests := []struct {
+		expr      string
+		pageCount int
+		wantToken string // the token the error must name, or the expression for whole-selection errors
+	}{
+		{"abc", 5, `"abc"`},
+		{"1-3,x", 5, `"x"`},
+		{"0", 5, `"0"`},
+		{"-", 5, `"-"`},
+		{"1-2-3", 5, `"1-2-3"`},
+		{"!", 5, `"!"`},
+		{"3 4", 5, `"3 4"`},
+		{"lastly", 5, `"lastly"`},
+		{"1-99999999999999999999", 5, `"1-99999999999999999999"`},
+		{",", 5, `","`},
+		{"6", 5, `"6"`},
+		{"4-", 3, `"4-"`},
+		{"5-2", 5, `"5-2"`},
+		{"last-5", 5, `"last-5"`},
+		{"1-3,!1-3", 3, `"1-3,!1-3"`},
+		{"even", 1, `"even"`},
+		{"!3,3", 4, `"!3,3"`},
+	}
+
+	for _, tt := range tests {
+		sel, err := ParsePageSelector(tt.expr)
+		if err == nil {
+			_, err = sel.Pages(tt.pageCount)
+		}
+		if err == nil {
+			t.Errorf("%q with %d pages: expected an error", tt.expr, tt.pageCount)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.wantToken) {
+			t.Errorf("%q with %d pages: error %q does not name %s", tt.expr, tt.pageCount, err, tt.wantToken)
+		}
+	}
+}
+
+func FuzzPageSelector(f *testing.F) {
+	for _, seed := range []string{"", "1-3,5", "5-", "-3", "last-2", "3-last-1", "odd,!7", "even", "!1", "1-20,!7", "end-1-"} {
+		f.Add(seed, 10)
+	}
+
+	f.Fuzz(func(t *testing.T, expr string, pageCount int) {
+		sel, err := ParsePageSelector(expr)
+		if err != nil {
+			return
+		}
+		if pageCount < 1 || pageCount > 10000 {
+			return
+		}
+
+		pages, err := sel.Pages(pageCount)
+		if err != nil {
+			return
+		}
+
+		if len(pages) == 0 {
+			t.Fatalf("%q.Pages(%d) returned no pages and no error", expr, pageCount)
+		}
+		for i, page := range pages {
+			if page < 1 || page > pageCount {
+				t.Fatalf("%q.Pages(%d) returned out of range page %d", expr, pageCount, page)
+			}
+			if i > 0 && page <= pages[i-1] {
+				t.Fatalf("%q.Pages(%d) = %v is not strictly ascending", expr, pageCount, pages)
+			}
+		}
+	})
+}
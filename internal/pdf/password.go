@@ -1,23 +1,63 @@
 package pdf
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
 	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
 )
 
 // RemovePDFPassword removes password protection from a PDF file.
 // The output PDF will have no password and can be shared freely.
-func RemovePDFPassword(inputPath, outputPath, password string) error {
-	// Create configuration with the current password
-	conf := model.NewDefaultConfiguration()
-	conf.UserPW = password
+// password may be empty for documents that open without one but restrict
+// printing, copying and so on; the restrictions that were lifted are returned.
+func RemovePDFPassword(inputPath, outputPath, password string) ([]string, error) {
+	// Inspecting fails the same way decrypting would if a password is needed
+	info, err := Inspect(inputPath, password)
+	switch {
+	case errors.Is(err, pdfcpu.ErrWrongPassword) && password == "":
+		return nil, fmt.Errorf("PDF needs a password to open")
+	case errors.Is(err, pdfcpu.ErrWrongPassword):
+		return nil, fmt.Errorf("incorrect password")
+	case err != nil:
+		return nil, err
+	case !info.Encrypted:
+		return nil, fmt.Errorf("PDF is not password protected")
+	}
+	restrictions := info.Permissions.Denied()
 
 	// Decrypt the PDF - this creates a new PDF without any password protection
-	if err := api.DecryptFile(inputPath, outputPath, conf); err != nil {
-		return fmt.Errorf("failed to remove password: %w", err)
+	for _, conf := range passwordConfigs(password) {
+		if err = api.DecryptFile(inputPath, outputPath, conf); !errors.Is(err, pdfcpu.ErrWrongPassword) {
+			break
+		}
+	}
+	if err != nil {
+		// pdfcpu only lifts restrictions on PDFs that open without a password
+		// when it is given the owner password, not the password to open
+		if password != "" && len(restrictions) > 0 {
+			return nil, fmt.Errorf("this PDF restricts %s; enter its owner password", strings.Join(restrictions, ", "))
+		}
+		return nil, fmt.Errorf("failed to remove password: %w", err)
+	}
+
+	return restrictions, nil
+}
+
+// passwordConfigs returns configurations that try password as the user password
+// and then as the owner password. pdfcpu's RC4 owner password check overwrites
+// the user password, so both cannot be tried in one read.
+func passwordConfigs(password string) []*model.Configuration {
+	userConf := model.NewDefaultConfiguration()
+	userConf.UserPW = password
+	if password == "" {
+		return []*model.Configuration{userConf}
 	}
 
-	return nil
+	ownerConf := model.NewDefaultConfiguration()
+	ownerConf.OwnerPW = password
+	return []*model.Configuration{userConf, ownerConf}
 }
@@ -0,0 +1,102 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// Permissions are the user access permissions of an encrypted PDF
+type Permissions struct {
+	Print         bool `json:"print"`         // Print, possibly at degraded quality
+	PrintHighRes  bool `json:"printHighRes"`  // Print at full quality
+	Copy          bool `json:"copy"`          // Copy or extract text and graphics
+	Modify        bool `json:"modify"`        // Change contents other than annotations, forms and page assembly
+	Annotate      bool `json:"annotate"`      // Add or modify annotations and fill in forms
+	FillForms     bool `json:"fillForms"`     // Fill in existing form fields
+	Assemble      bool `json:"assemble"`      // Insert, rotate or delete pages and create bookmarks
+	Accessibility bool `json:"accessibility"` // Extract text and graphics for accessibility tools
+}
+
+// AllPermissions grants everything a document's owner can do
+var AllPermissions = Permissions{true, true, true, true, true, true, true, true}
+
+// permissionBits pairs each permission with its name, a description of what it
+// allows and its bit in the P entry of the encryption dictionary
+var permissionBits = []struct {
+	name  string
+	label string
+	flag  model.PermissionFlags
+	field func(*Permissions) *bool
+}{
+	{"print", "printing", model.PermissionPrintRev2, func(p *Permissions) *bool { return &p.Print }},
+	{"printHighRes", "high-resolution printing", model.PermissionPrintRev3, func(p *Permissions) *bool { return &p.PrintHighRes }},
+	{"copy", "copying text and images", model.PermissionExtract, func(p *Permissions) *bool { return &p.Copy }},
+	{"modify", "modifying contents", model.PermissionModify, func(p *Permissions) *bool { return &p.Modify }},
+	{"annotate", "adding comments", model.PermissionModAnnFillForm, func(p *Permissions) *bool { return &p.Annotate }},
+	{"fillForms", "filling in forms", model.PermissionFillRev3, func(p *Permissions) *bool { return &p.FillForms }},
+	{"assemble", "inserting, rotating and deleting pages", model.PermissionAssembleRev3, func(p *Permissions) *bool { return &p.Assemble }},
+	{"accessibility", "text access for accessibility tools", model.PermissionExtractRev3, func(p *Permissions) *bool { return &p.Accessibility }},
+}
+
+// ParsePermissions builds permissions from names like "print" or "fillForms".
+// Permissions that are not named are denied.
+func ParsePermissions(names []string) (Permissions, error) {
+	var perms Permissions
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		found := false
+		for _, bit := range permissionBits {
+			if strings.EqualFold(name, bit.name) {
+				*bit.field(&perms) = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return Permissions{}, fmt.Errorf("unknown permission %q", name)
+		}
+	}
+	return perms, nil
+}
+
+// Restricted reports whether any permission is denied
+func (p Permissions) Restricted() bool {
+	return p != AllPermissions
+}
+
+// Denied describes the permissions that are not granted, e.g. "printing"
+func (p Permissions) Denied() []string {
+	var denied []string
+	for _, bit := range permissionBits {
+		if !*bit.field(&p) {
+			denied = append(denied, bit.label)
+		}
+	}
+	return denied
+}
+
+// flags encodes the permissions as the P entry of an encryption dictionary
+func (p Permissions) flags() model.PermissionFlags {
+	flags := model.PermissionsNone
+	for _, bit := range permissionBits {
+		if *bit.field(&p) {
+			flags |= bit.flag
+		}
+	}
+	return flags
+}
+
+// permissionsFromBits decodes the P entry of an encryption dictionary
+func permissionsFromBits(p int) Permissions {
+	var perms Permissions
+	for _, bit := range permissionBits {
+		*bit.field(&perms) = p&int(bit.flag) != 0
+	}
+	return perms
+}
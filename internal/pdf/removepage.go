@@ -2,19 +2,38 @@ package pdf
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/pdfcpu/pdfcpu/pkg/api"
 	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
 )
 
 // RemovePDFPages removes specific pages from a PDF file.
-// pageRange should be in format like "1,3,5" or "1-3,5,7-9"
+// pageRange is a PageSelector expression like "1,3,5", "1-3,last" or "even"
 func RemovePDFPages(inputPath, outputPath, pageRange string) error {
+	if strings.TrimSpace(pageRange) == "" {
+		return fmt.Errorf("no pages specified")
+	}
+
+	pageCount, err := api.PageCountFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	pages, err := selectPages(pageRange, pageCount)
+	if err != nil {
+		return fmt.Errorf("invalid page range: %w", err)
+	}
+
+	if len(pages) == pageCount {
+		return fmt.Errorf("cannot remove all %d pages", pageCount)
+	}
+
 	// Create default configuration
 	conf := model.NewDefaultConfiguration()
 
 	// Remove pages from PDF
-	if err := api.RemovePagesFile(inputPath, outputPath, []string{pageRange}, conf); err != nil {
+	if err := api.RemovePagesFile(inputPath, outputPath, pageStrings(pages), conf); err != nil {
 		return fmt.Errorf("failed to remove pages: %w", err)
 	}
 
@@ -0,0 +1,158 @@
+package pdf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// pageRotation is a page selection paired with the rotation to apply to it
+type pageRotation struct {
+	pages   string
+	degrees int
+}
+
+// RotatePages rotates pages of a PDF file clockwise.
+// pageRange is a PageSelector expression ("1-3,5", "odd"); an empty range rotates every page.
+// A rotation applies to the entries since the previous one, e.g. "1-3:90,7:180" or
+// "1-20,!7:90", and entries after the last rotation use degrees. Exclusions right
+// after a rotation belong to the entries before it, so "1-3:90,!2" leaves page 2 alone.
+// If a page is selected more than once the last selection wins.
+func RotatePages(inputPath, outputPath, pageRange string, degrees int) error {
+	rotations, err := parseRotations(pageRange, degrees)
+	if err != nil {
+		return fmt.Errorf("invalid rotation: %w", err)
+	}
+
+	ctx, err := api.ReadContextFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	perPage, err := rotationsByPage(rotations, ctx.PageCount)
+	if err != nil {
+		return err
+	}
+
+	// Group pages by rotation so each angle is applied in a single pass
+	groups := map[int]types.IntSet{}
+	for page, deg := range perPage {
+		if deg == 0 {
+			continue
+		}
+		if groups[deg] == nil {
+			groups[deg] = types.IntSet{}
+		}
+		groups[deg][page] = true
+	}
+
+	for deg, pages := range groups {
+		if err := pdfcpu.RotatePages(ctx, pages, deg); err != nil {
+			return fmt.Errorf("failed to rotate pages: %w", err)
+		}
+	}
+
+	if err := api.WriteContextFile(ctx, outputPath); err != nil {
+		return fmt.Errorf("failed to write PDF: %w", err)
+	}
+
+	return nil
+}
+
+// rotationsByPage resolves rotations to the rotation of each selected page.
+// Every selection is resolved as a whole so its exclusions apply to all its
+// entries, and later selections override earlier ones.
+func rotationsByPage(rotations []pageRotation, pageCount int) (map[int]int, error) {
+	perPage := map[int]int{}
+	for _, rot := range rotations {
+		pages, err := selectPages(rot.pages, pageCount)
+		if err != nil {
+			return nil, fmt.Errorf("invalid page range: %w", err)
+		}
+
+		for _, page := range pages {
+			perPage[page] = rot.degrees
+		}
+	}
+	return perPage, nil
+}
+
+// parseRotations parses rotation specs like "1-3:90,7:180" into page selections
+// and their rotation. Entries are grouped up to the next one with a rotation,
+// except that exclusions directly after a rotation are added to the group before.
+func parseRotations(spec string, defaultDegrees int) ([]pageRotation, error) {
+	def, err := normalizeRotation(defaultDegrees)
+	if err != nil {
+		return nil, err
+	}
+
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return []pageRotation{{degrees: def}}, nil
+	}
+
+	var rotations []pageRotation
+	var group []string
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		pages, degStr, found := strings.Cut(part, ":")
+		pages = strings.TrimSpace(pages)
+		if pages == "" {
+			return nil, fmt.Errorf("missing pages in %q", part)
+		}
+
+		// On its own "!2" would select every other page, overriding the
+		// rotation the user just gave them
+		if strings.HasPrefix(pages, "!") && len(group) == 0 && len(rotations) > 0 {
+			if found {
+				return nil, fmt.Errorf("%q excludes pages from the entries before it and cannot have its own rotation", part)
+			}
+			last := &rotations[len(rotations)-1]
+			last.pages += "," + pages
+			continue
+		}
+
+		group = append(group, pages)
+		if !found {
+			continue
+		}
+
+		parsed, err := strconv.Atoi(strings.TrimSpace(degStr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid degrees in %q", part)
+		}
+		deg, err := normalizeRotation(parsed)
+		if err != nil {
+			return nil, err
+		}
+
+		rotations = append(rotations, pageRotation{pages: strings.Join(group, ","), degrees: deg})
+		group = nil
+	}
+
+	if len(group) > 0 {
+		rotations = append(rotations, pageRotation{pages: strings.Join(group, ","), degrees: def})
+	}
+
+	if len(rotations) == 0 {
+		return nil, fmt.Errorf("no pages specified")
+	}
+
+	return rotations, nil
+}
+
+// normalizeRotation maps a multiple of 90 onto 0, 90, 180 or 270
+func normalizeRotation(degrees int) (int, error) {
+	if degrees%90 != 0 {
+		return 0, fmt.Errorf("rotation must be a multiple of 90 degrees, got %d", degrees)
+	}
+	return (degrees%360 + 360) % 360, nil
+}
@@ -0,0 +1,75 @@
+package pdf
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestRotationsByPage(t *testing.T) {
+	// pagesAt rotates pages from to thru by deg, except those in skip
+	pagesAt := func(from, thru, deg int, skip ...int) map[int]int {
+		m := map[int]int{}
+		for page := from; page <= thru; page++ {
+			m[page] = deg
+		}
+		for _, page := range skip {
+			delete(m, page)
+		}
+		return m
+	}
+
+	tests := []struct {
+		spec      string
+		degrees   int
+		pageCount int
+		want      map[int]int
+	}{
+		{"", 90, 3, pagesAt(1, 3, 90)},
+		{"1-20,!7", 90, 25, pagesAt(1, 20, 90, 7)},
+		{"1-20,!7:180", 90, 25, pagesAt(1, 20, 180, 7)},
+		{"1-3:90,7:180", 270, 10, map[int]int{1: 90, 2: 90, 3: 90, 7: 180}},
+		{"1,2:90,5", 180, 5, map[int]int{1: 90, 2: 90, 5: 180}},
+		{"1-3:90,!2", 180, 5, map[int]int{1: 90, 3: 90}},
+		{"1-5:90,!2,!4,7:180", 270, 8, map[int]int{1: 90, 3: 90, 5: 90, 7: 180}},
+		{"1-3:90,!2,5", 180, 5, map[int]int{1: 90, 3: 90, 5: 180}},
+		{"!2:90", 180, 3, map[int]int{1: 90, 3: 90}},
+		{"odd:90,last:180", 90, 5, map[int]int{1: 90, 3: 90, 5: 180}},
+		{"1-4:-90,2:0", 90, 4, map[int]int{1: 270, 2: 0, 3: 270, 4: 270}},
+	}
+
+	for _, tt := range tests {
+		rotations, err := parseRotations(tt.spec, tt.degrees)
+		if err != nil {
+			t.Errorf("%q: parseRotations error: %v", tt.spec, err)
+			continue
+		}
+		got, err := rotationsByPage(rotations, tt.pageCount)
+		if err != nil {
+			t.Errorf("%q: rotationsByPage error: %v", tt.spec, err)
+			continue
+		}
+		if !maps.Equal(got, tt.want) {
+			t.Errorf("%q: rotations = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestParseRotationsErrors(t *testing.T) {
+	tests := []struct {
+		spec    string
+		degrees int
+	}{
+		{"1-3", 45},
+		{"1:45", 90},
+		{"1:ninety", 90},
+		{":90", 90},
+		{" , ", 90},
+		{"1-3:90,!2:180", 90},
+	}
+
+	for _, tt := range tests {
+		if rotations, err := parseRotations(tt.spec, tt.degrees); err == nil {
+			t.Errorf("%q: parseRotations = %v, want error", tt.spec, rotations)
+		}
+	}
+}
@@ -2,16 +2,20 @@ package pdf
 
 import (
 	"archive/zip"
+	"bytes"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
 )
 
 // generateID creates a unique identifier for file naming
@@ -24,58 +28,379 @@ func generateID() string {
 type SplitMode string
 
 const (
-	SplitAll   SplitMode = "all"
-	SplitRange SplitMode = "range"
+	SplitAll         SplitMode = "all"
+	SplitRange       SplitMode = "range"
+	SplitEvery       SplitMode = "every"
+	SplitByBookmarks SplitMode = "bookmarks"
+	SplitBySize      SplitMode = "size"
+	SplitGroups      SplitMode = "groups"
 )
 
-// SplitPDF splits a PDF file based on the specified mode
-func SplitPDF(inputPath, outputDir string, mode SplitMode, pageRange string) (string, error) {
-	if mode == SplitAll {
-		return splitAllPages(inputPath, outputDir)
+// SplitOptions holds the settings used by the different split modes
+type SplitOptions struct {
+	PageRange string   // Pages to extract for SplitRange
+	Every     int      // Pages per file for SplitEvery
+	MaxBytes  int64    // Size limit per file for SplitBySize
+	Groups    string   // Semicolon-separated page selections for SplitGroups, e.g. "1-3;4-10;11-"
+	PartNames []string // Optional file names for the SplitGroups parts, in order
+	Name      string   // Original file name, used to name the files in the ZIP
+}
+
+// SplitResult is the file a split produced
+type SplitResult struct {
+	Path           string // The PDF or ZIP of PDFs
+	OversizedPages []int  // Pages that alone exceed the size limit of SplitBySize, each in a file of its own
+}
+
+// pageChunk is a group of pages written to a single output file
+type pageChunk struct {
+	name  string
+	pages []int
+}
+
+// SplitPDF splits a PDF file based on the specified mode.
+// SplitRange returns a single PDF; every other mode returns a ZIP of PDFs.
+func SplitPDF(inputPath, outputDir string, mode SplitMode, opts SplitOptions) (SplitResult, error) {
+	var result SplitResult
+	var err error
+	switch mode {
+	case SplitAll:
+		result.Path, err = splitEvery(inputPath, outputDir, 1, opts.Name)
+	case SplitRange:
+		result.Path, err = splitByRange(inputPath, outputDir, opts.PageRange)
+	case SplitEvery:
+		result.Path, err = splitEvery(inputPath, outputDir, opts.Every, opts.Name)
+	case SplitByBookmarks:
+		result.Path, err = splitByBookmarks(inputPath, outputDir)
+	case SplitBySize:
+		result.Path, result.OversizedPages, err = splitBySize(inputPath, outputDir, opts.MaxBytes, opts.Name)
+	case SplitGroups:
+		result.Path, err = splitGroups(inputPath, outputDir, opts.Groups, opts.PartNames, opts.Name)
+	default:
+		return SplitResult{}, fmt.Errorf("unknown split mode: %s", mode)
+	}
+	if err != nil {
+		return SplitResult{}, err
+	}
+	return result, nil
+}
+
+// splitEvery splits a PDF into files of n pages; the last file may be shorter
+func splitEvery(inputPath, outputDir string, n int, name string) (string, error) {
+	if n < 1 {
+		return "", fmt.Errorf("pages per file must be at least 1")
+	}
+
+	ctx, err := readSplitContext(inputPath)
+	if err != nil {
+		return "", err
+	}
+
+	stem := fileStem(name)
+	var chunks []pageChunk
+	for from := 1; from <= ctx.PageCount; from += n {
+		thru := min(from+n-1, ctx.PageCount)
+		label := spanLabel(from, thru, ctx.PageCount)
+		if n == 1 {
+			label = fmt.Sprintf("page_%0*d", len(strconv.Itoa(ctx.PageCount)), from)
+		}
+		chunks = append(chunks, pageChunk{
+			name:  stem + "_" + label + ".pdf",
+			pages: pageSpan(from, thru),
+		})
+	}
+
+	return writeChunksZip(ctx, outputDir, chunks)
+}
+
+// splitByBookmarks writes one file per top-level bookmark, named after its title.
+// Pages before the first bookmark are written to a "front matter" file.
+func splitByBookmarks(inputPath, outputDir string) (string, error) {
+	ctx, err := readSplitContext(inputPath)
+	if err != nil {
+		return "", err
+	}
+
+	bookmarks, err := pdfcpu.Bookmarks(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to read bookmarks: %w", err)
+	}
+
+	// Only bookmarks pointing at a page can start a file
+	var starts []pdfcpu.Bookmark
+	for _, bm := range bookmarks {
+		if bm.PageFrom >= 1 && bm.PageFrom <= ctx.PageCount {
+			starts = append(starts, bm)
+		}
+	}
+	if len(starts) == 0 {
+		return "", fmt.Errorf("PDF has no bookmarks to split by")
+	}
+	sort.SliceStable(starts, func(i, j int) bool { return starts[i].PageFrom < starts[j].PageFrom })
+
+	var chunks []pageChunk
+	if starts[0].PageFrom > 1 {
+		chunks = append(chunks, pageChunk{name: "front matter", pages: pageSpan(1, starts[0].PageFrom-1)})
+	}
+	for i, bm := range starts {
+		thru := ctx.PageCount
+		if i+1 < len(starts) {
+			// Bookmarks sharing a start page each get that page
+			thru = max(bm.PageFrom, starts[i+1].PageFrom-1)
+		}
+		chunks = append(chunks, pageChunk{name: bm.Title, pages: pageSpan(bm.PageFrom, thru)})
+	}
+
+	// Number the files so they sort in reading order
+	width := len(strconv.Itoa(len(chunks)))
+	for i := range chunks {
+		chunks[i].name = fmt.Sprintf("%0*d_%s.pdf", width, i+1, safeFileName(chunks[i].name))
+	}
+
+	return writeChunksZip(ctx, outputDir, chunks)
+}
+
+// splitBySize greedily fills each file with as many pages as fit under maxBytes.
+// A page that alone is over the limit gets a file of its own, named with an
+// "over_limit" suffix, and is returned among the oversized pages.
+func splitBySize(inputPath, outputDir string, maxBytes int64, name string) (string, []int, error) {
+	if maxBytes < 1 {
+		return "", nil, fmt.Errorf("size limit must be positive")
+	}
+
+	ctx, err := readSplitContext(inputPath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	stem := fileStem(name)
+	var chunks []pageChunk
+	var oversized []int
+	for from := 1; from <= ctx.PageCount; {
+		thru := from
+		size, err := spanSize(ctx, from, thru)
+		if err != nil {
+			return "", nil, err
+		}
+		if size > maxBytes {
+			chunks = append(chunks, pageChunk{
+				name:  stem + "_" + spanLabel(from, thru, ctx.PageCount) + "_over_limit.pdf",
+				pages: pageSpan(from, thru),
+			})
+			oversized = append(oversized, from)
+			from++
+			continue
+		}
+
+		// Double the chunk until it no longer fits, then binary search for the last page that does
+		fits, tooBig := thru, ctx.PageCount+1
+		for step := 1; fits+step < tooBig; step *= 2 {
+			size, err := spanSize(ctx, from, fits+step)
+			if err != nil {
+				return "", nil, err
+			}
+			if size > maxBytes {
+				tooBig = fits + step
+				break
+			}
+			fits += step
+		}
+		for tooBig-fits > 1 {
+			mid := (fits + tooBig) / 2
+			size, err := spanSize(ctx, from, mid)
+			if err != nil {
+				return "", nil, err
+			}
+			if size > maxBytes {
+				tooBig = mid
+			} else {
+				fits = mid
+			}
+		}
+		thru = fits
+
+		chunks = append(chunks, pageChunk{
+			name:  stem + "_" + spanLabel(from, thru, ctx.PageCount) + ".pdf",
+			pages: pageSpan(from, thru),
+		})
+		from = thru + 1
+	}
+
+	zipPath, err := writeChunksZip(ctx, outputDir, chunks)
+	if err != nil {
+		return "", nil, err
+	}
+	return zipPath, oversized, nil
+}
+
+// splitGroups writes one file per semicolon-separated page selection.
+// Parts without a name in partNames are named after the input file and their position.
+func splitGroups(inputPath, outputDir, groups string, partNames []string, name string) (string, error) {
+	var selections []string
+	for _, group := range strings.Split(groups, ";") {
+		if group = strings.TrimSpace(group); group != "" {
+			selections = append(selections, group)
+		}
+	}
+	if len(selections) == 0 {
+		return "", fmt.Errorf("no page groups specified")
+	}
+	if len(partNames) > len(selections) {
+		return "", fmt.Errorf("%d part names given for %d page groups", len(partNames), len(selections))
+	}
+
+	ctx, err := readSplitContext(inputPath)
+	if err != nil {
+		return "", err
 	}
-	return splitByRange(inputPath, outputDir, pageRange)
+
+	stem := fileStem(name)
+	width := len(strconv.Itoa(len(selections)))
+	chunks := make([]pageChunk, len(selections))
+	for i, selection := range selections {
+		pages, err := selectPages(selection, ctx.PageCount)
+		if err != nil {
+			return "", fmt.Errorf("invalid page group %d: %w", i+1, err)
+		}
+
+		chunks[i] = pageChunk{
+			name:  fmt.Sprintf("%s_part_%0*d.pdf", stem, width, i+1),
+			pages: pages,
+		}
+		if i < len(partNames) && strings.TrimSpace(partNames[i]) != "" {
+			chunks[i].name = fileStem(partNames[i]) + ".pdf"
+		}
+	}
+
+	return writeChunksZip(ctx, outputDir, chunks)
+}
+
+// readSplitContext reads a PDF file for extracting page spans
+func readSplitContext(inputPath string) (*model.Context, error) {
+	f, err := os.Open(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer f.Close()
+
+	conf := model.NewDefaultConfiguration()
+	conf.Cmd = model.SPLIT
+
+	ctx, err := api.ReadValidateAndOptimize(f, conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	return ctx, nil
 }
 
-// splitAllPages splits PDF into individual pages
-func splitAllPages(inputPath, outputDir string) (string, error) {
+// spanSize returns the size of a PDF holding pages from through thru
+func spanSize(ctx *model.Context, from, thru int) (int64, error) {
+	ctxDest, err := pdfcpu.ExtractPages(ctx, pageSpan(from, thru), false)
+	if err != nil {
+		return 0, fmt.Errorf("failed to extract pages: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := api.WriteContext(ctxDest, &buf); err != nil {
+		return 0, fmt.Errorf("failed to write pages: %w", err)
+	}
+	return int64(buf.Len()), nil
+}
+
+// writeChunksZip writes each chunk to its own PDF and returns a ZIP of all of them
+func writeChunksZip(ctx *model.Context, outputDir string, chunks []pageChunk) (string, error) {
 	// Create unique output directory for this split operation using MkdirTemp
-	// This guarantees a unique directory name and avoids collisions
 	splitDir, err := os.MkdirTemp(outputDir, "split_")
 	if err != nil {
 		return "", fmt.Errorf("failed to create output directory: %w", err)
 	}
+	defer os.RemoveAll(splitDir)
 
-	// Split into individual pages
-	if err := api.SplitFile(inputPath, splitDir, 1, nil); err != nil {
-		os.RemoveAll(splitDir) // Clean up on error
-		return "", fmt.Errorf("failed to split PDF: %w", err)
+	used := map[string]bool{}
+	for _, chunk := range chunks {
+		ctxDest, err := pdfcpu.ExtractPages(ctx, chunk.pages, false)
+		if err != nil {
+			return "", fmt.Errorf("failed to extract pages: %w", err)
+		}
+
+		path := filepath.Join(splitDir, uniqueFileName(chunk.name, used))
+		if err := api.WriteContextFile(ctxDest, path); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", chunk.name, err)
+		}
 	}
 
 	// Create ZIP file with unique name based on the split directory name
-	zipName := filepath.Base(splitDir) + ".zip"
-	zipPath := filepath.Join(outputDir, zipName)
-
+	zipPath := filepath.Join(outputDir, filepath.Base(splitDir)+".zip")
 	if err := zipDirectory(splitDir, zipPath); err != nil {
-		os.RemoveAll(splitDir) // Clean up on error
+		os.Remove(zipPath)
 		return "", fmt.Errorf("failed to create ZIP: %w", err)
 	}
 
-	// Clean up the temporary split directory after zipping
-	os.RemoveAll(splitDir)
-
 	return zipPath, nil
 }
 
+// pageSpan lists the pages from through thru
+func pageSpan(from, thru int) []int {
+	pages := make([]int, 0, thru-from+1)
+	for i := from; i <= thru; i++ {
+		pages = append(pages, i)
+	}
+	return pages
+}
+
+// spanLabel names a page span like "pages_01-05" or "pages_07", padded to sort by page
+func spanLabel(from, thru, pageCount int) string {
+	width := len(strconv.Itoa(pageCount))
+	if from == thru {
+		return fmt.Sprintf("pages_%0*d", width, from)
+	}
+	return fmt.Sprintf("pages_%0*d-%0*d", width, from, width, thru)
+}
+
+// fileStem returns a file name without directory and extension, safe to reuse in output names
+func fileStem(name string) string {
+	base := filepath.Base(name)
+	stem := strings.TrimSuffix(base, filepath.Ext(base))
+	if strings.TrimSpace(stem) == "" || stem == "." {
+		return "document"
+	}
+	return safeFileName(stem)
+}
+
+// safeFileName replaces characters that are not allowed in file names on common systems
+func safeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+
+	name = strings.Trim(strings.TrimSpace(name), ".")
+	if len([]rune(name)) > 80 {
+		name = strings.TrimSpace(string([]rune(name)[:80]))
+	}
+	if name == "" {
+		return "untitled"
+	}
+	return name
+}
+
 // splitByRange extracts specific pages from PDF
 func splitByRange(inputPath, outputDir, pageRange string) (string, error) {
-	// Parse page ranges (e.g., "1-3,5,7-9")
-	pages, err := parsePageRanges(pageRange)
+	if strings.TrimSpace(pageRange) == "" {
+		return "", fmt.Errorf("no pages specified")
+	}
+
+	pageCount, err := api.PageCountFile(inputPath)
 	if err != nil {
-		return "", fmt.Errorf("invalid page range: %w", err)
+		return "", fmt.Errorf("failed to read PDF: %w", err)
 	}
 
-	if len(pages) == 0 {
-		return "", fmt.Errorf("no valid pages specified")
+	// Resolve the selection (e.g., "1-3,5,last") against the document
+	pages, err := selectPages(pageRange, pageCount)
+	if err != nil {
+		return "", fmt.Errorf("invalid page range: %w", err)
 	}
 
 	// Create temp file for output to ensure uniqueness
@@ -85,11 +410,10 @@ func splitByRange(inputPath, outputDir, pageRange string) (string, error) {
 	}
 	outputPath := tmpFile.Name()
 	tmpFile.Close()
-	// We only needed the name, ExtractPagesFile will overwrite/write to it
-	// But ExtractPagesFile expects the file to NOT be open.
+	// We only needed the name, ReorderPages will overwrite it
 
-	// Extract specified pages
-	if err := api.ExtractPagesFile(inputPath, outputPath, pages, nil); err != nil {
+	// Extract specified pages into a single PDF
+	if err := ReorderPages(inputPath, outputPath, pages); err != nil {
 		os.Remove(outputPath)
 		return "", fmt.Errorf("failed to extract pages: %w", err)
 	}
@@ -97,54 +421,6 @@ func splitByRange(inputPath, outputDir, pageRange string) (string, error) {
 	return outputPath, nil
 }
 
-// parsePageRanges parses page range strings like "1-3,5,7-9"
-func parsePageRanges(rangeStr string) ([]string, error) {
-	var pages []string
-	parts := strings.Split(rangeStr, ",")
-
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		if part == "" {
-			continue
-		}
-
-		if strings.Contains(part, "-") {
-			// Range like "1-3"
-			rangeParts := strings.Split(part, "-")
-			if len(rangeParts) != 2 {
-				return nil, fmt.Errorf("invalid range: %s", part)
-			}
-
-			start, err := strconv.Atoi(strings.TrimSpace(rangeParts[0]))
-			if err != nil {
-				return nil, fmt.Errorf("invalid start page: %s", rangeParts[0])
-			}
-
-			end, err := strconv.Atoi(strings.TrimSpace(rangeParts[1]))
-			if err != nil {
-				return nil, fmt.Errorf("invalid end page: %s", rangeParts[1])
-			}
-
-			if start > end {
-				return nil, fmt.Errorf("invalid range: start > end (%d > %d)", start, end)
-			}
-
-			for i := start; i <= end; i++ {
-				pages = append(pages, strconv.Itoa(i))
-			}
-		} else {
-			// Single page
-			pageNum, err := strconv.Atoi(part)
-			if err != nil {
-				return nil, fmt.Errorf("invalid page number: %s", part)
-			}
-			pages = append(pages, strconv.Itoa(pageNum))
-		}
-	}
-
-	return pages, nil
-}
-
 // zipDirectory creates a ZIP file from a directory
 func zipDirectory(sourceDir, zipPath string) error {
 	zipFile, err := os.Create(zipPath)
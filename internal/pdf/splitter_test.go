@@ -0,0 +1,83 @@
+package pdf
+
+import (
+	"archive/zip"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeSizedPDF writes a PDF with one page per entry of sizes, each carrying
+// about that many bytes of content that compresses poorly, and returns its path
+func writeSizedPDF(t *testing.T, sizes ...int) string {
+	t.Helper()
+
+	rng := rand.New(rand.NewSource(1))
+	n := len(sizes)
+	kids := make([]string, n)
+	objects := []string{"<< /Type /Catalog /Pages 2 0 R >>", ""}
+	for i := range sizes {
+		kids[i] = fmt.Sprintf("%d 0 R", 3+i)
+		objects = append(objects, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents %d 0 R >>", 3+n+i))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), n)
+	for _, size := range sizes {
+		noise := make([]byte, size/2)
+		rng.Read(noise)
+		content := "% " + hex.EncodeToString(noise)
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+	return writeTestPDF(t, objects, "")
+}
+
+func TestSplitBySize(t *testing.T) {
+	tests := []struct {
+		name      string
+		sizes     []int
+		files     []string
+		oversized []int
+	}{
+		{"fits", []int{1000, 1000, 1000}, []string{"doc_pages_1-3.pdf"}, nil},
+		{"greedy", []int{30000, 30000, 30000}, []string{"doc_pages_1-2.pdf", "doc_pages_3.pdf"}, nil},
+		{
+			"oversized page",
+			[]int{1000, 200000, 1000, 1000},
+			[]string{"doc_pages_1.pdf", "doc_pages_2_over_limit.pdf", "doc_pages_3-4.pdf"},
+			[]int{2},
+		},
+		{
+			"all oversized",
+			[]int{200000, 200000},
+			[]string{"doc_pages_1_over_limit.pdf", "doc_pages_2_over_limit.pdf"},
+			[]int{1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		result, err := SplitPDF(writeSizedPDF(t, tt.sizes...), t.TempDir(), SplitBySize, SplitOptions{MaxBytes: 80000, Name: "doc.pdf"})
+		if err != nil {
+			t.Errorf("%s: SplitPDF error: %v", tt.name, err)
+			continue
+		}
+		if !slices.Equal(result.OversizedPages, tt.oversized) {
+			t.Errorf("%s: oversized pages = %v, want %v", tt.name, result.OversizedPages, tt.oversized)
+		}
+
+		r, err := zip.OpenReader(result.Path)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var files []string
+		for _, f := range r.File {
+			files = append(files, f.Name)
+		}
+		r.Close()
+		slices.Sort(files)
+		if !slices.Equal(files, tt.files) {
+			t.Errorf("%s: files = %v, want %v", tt.name, files, tt.files)
+		}
+	}
+}
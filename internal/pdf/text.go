@@ -0,0 +1,495 @@
+package pdf
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// TextOptions selects the pages to extract text from and the detail returned
+type TextOptions struct {
+	Pages string // PageSelector expression of the pages to extract; empty for all pages
+	Runs  bool   // Also return the positioned text runs of each page
+}
+
+// PageText is the text of one page
+type PageText struct {
+	Page int       `json:"page"`
+	Text string    `json:"text"`
+	Runs []TextRun `json:"runs,omitempty"`
+}
+
+// TextRun is a stretch of text on one baseline in one font and size.
+// X and Y are the start of its baseline in PDF points from the bottom left
+// corner of the unrotated page.
+type TextRun struct {
+	Text string  `json:"text"`
+	X    float64 `json:"x"`
+	Y    float64 `json:"y"`
+	Font string  `json:"font"`
+	Size float64 `json:"size"`
+
+	endX float64
+}
+
+// ExtractText returns the text of the selected pages in reading order of the
+// content streams, with line breaks and spaces inferred from glyph positions.
+// Text drawn as images or vector paths, such as in scanned pages, is not found.
+func ExtractText(inputPath string, opts TextOptions) ([]PageText, error) {
+	ctx, err := api.ReadContextFile(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	pages, err := selectPages(opts.Pages, ctx.PageCount)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page range: %w", err)
+	}
+
+	fonts := map[int]*textFont{}
+	result := make([]PageText, 0, len(pages))
+	for _, page := range pages {
+		runs, err := pageTextRuns(ctx, page, fonts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract text of page %d: %w", page, err)
+		}
+
+		pt := PageText{Page: page, Text: joinTextRuns(runs)}
+		if opts.Runs {
+			pt.Runs = runs
+		}
+		result = append(result, pt)
+	}
+
+	return result, nil
+}
+
+// WritePlainText writes the text of each page to a UTF-8 file, ending every page with a form feed
+func WritePlainText(path string, pages []PageText) error {
+	var b strings.Builder
+	for _, page := range pages {
+		b.WriteString(page.Text)
+		b.WriteString("\n\f")
+	}
+
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write text: %w", err)
+	}
+	return nil
+}
+
+// WriteTextJSON writes the text of each page to a JSON file
+func WriteTextJSON(path string, pages []PageText) error {
+	data, err := json.MarshalIndent(pages, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode text: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write text: %w", err)
+	}
+	return nil
+}
+
+// pageTextRuns interprets the content of a page and returns its text runs.
+// fonts caches the fonts loaded so far by object number.
+func pageTextRuns(ctx *model.Context, page int, fonts map[int]*textFont) ([]TextRun, error) {
+	d, _, inherited, err := ctx.PageDict(page, false)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := ctx.PageContent(d, page)
+	if err != nil && err != model.ErrNoContent {
+		return nil, err
+	}
+
+	e := textExtractor{ctx: ctx, fonts: fonts}
+	gs := textGraphicsState{ctm: identityMatrix, scale: 1}
+	if err := e.run(content, inherited.Resources, gs, 0); err != nil {
+		return nil, err
+	}
+	return e.runs, nil
+}
+
+// textMatrix is an affine transformation [a b c d e f]
+type textMatrix [6]float64
+
+var identityMatrix = textMatrix{1, 0, 0, 1, 0, 0}
+
+// multiply returns m followed by n
+func (m textMatrix) multiply(n textMatrix) textMatrix {
+	return textMatrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+// translate returns a translation by tx and ty followed by m
+func (m textMatrix) translate(tx, ty float64) textMatrix {
+	return textMatrix{1, 0, 0, 1, tx, ty}.multiply(m)
+}
+
+// textGraphicsState is the part of the graphics state that affects text
+type textGraphicsState struct {
+	ctm       textMatrix
+	font      *textFont
+	fontSize  float64
+	charSpace float64
+	wordSpace float64
+	scale     float64 // Horizontal scaling as a fraction
+	leading   float64
+	rise      float64
+}
+
+// textExtractor collects the text runs of a page while interpreting its content
+type textExtractor struct {
+	ctx   *model.Context
+	fonts map[int]*textFont
+	runs  []TextRun
+}
+
+// maxFormDepth limits how deeply form XObjects may be nested
+const maxFormDepth = 10
+
+// run interprets a content stream with the given resources and initial graphics state
+func (e *textExtractor) run(content []byte, resources types.Dict, gs textGraphicsState, depth int) error {
+	// Keep the text before a syntax error, as viewers show it too
+	ops, err := parseContent(content)
+	if err != nil && len(ops) == 0 {
+		return err
+	}
+
+	var (
+		stack     []textGraphicsState
+		tm, tlm   = identityMatrix, identityMatrix
+		fontCache = map[string]*textFont{}
+	)
+
+	for _, op := range ops {
+		args := op.operands
+		switch op.name {
+		case "q":
+			stack = append(stack, gs)
+		case "Q":
+			if len(stack) > 0 {
+				gs = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			if m, ok := matrixOperands(args); ok {
+				gs.ctm = m.multiply(gs.ctm)
+			}
+		case "BT":
+			tm, tlm = identityMatrix, identityMatrix
+		case "Tf":
+			if len(args) == 2 {
+				if name, ok := args[0].(contentName); ok {
+					gs.font = e.font(resources, string(name), fontCache)
+				}
+				gs.fontSize = numberOperand(args[1])
+			}
+		case "Tc":
+			gs.charSpace = lastNumber(args)
+		case "Tw":
+			gs.wordSpace = lastNumber(args)
+		case "Tz":
+			gs.scale = lastNumber(args) / 100
+		case "TL":
+			gs.leading = lastNumber(args)
+		case "Ts":
+			gs.rise = lastNumber(args)
+		case "Td", "TD":
+			if len(args) == 2 {
+				tx, ty := numberOperand(args[0]), numberOperand(args[1])
+				if op.name == "TD" {
+					gs.leading = -ty
+				}
+				tlm = tlm.translate(tx, ty)
+				tm = tlm
+			}
+		case "Tm":
+			if m, ok := matrixOperands(args); ok {
+				tm, tlm = m, m
+			}
+		case "T*":
+			tlm = tlm.translate(0, -gs.leading)
+			tm = tlm
+		case "Tj":
+			if len(args) == 1 {
+				if s, ok := args[0].([]byte); ok {
+					tm = e.show(s, tm, gs)
+				}
+			}
+		case "'", "\"":
+			if op.name == "\"" && len(args) == 3 {
+				gs.wordSpace, gs.charSpace = numberOperand(args[0]), numberOperand(args[1])
+			}
+			tlm = tlm.translate(0, -gs.leading)
+			tm = tlm
+			if len(args) > 0 {
+				if s, ok := args[len(args)-1].([]byte); ok {
+					tm = e.show(s, tm, gs)
+				}
+			}
+		case "TJ":
+			if len(args) != 1 {
+				continue
+			}
+			elems, _ := args[0].([]any)
+			for _, elem := range elems {
+				switch v := elem.(type) {
+				case []byte:
+					tm = e.show(v, tm, gs)
+				case float64:
+					// Numbers move the next glyph left by thousandths of text space
+					tm = tm.translate(-v/1000*gs.fontSize*gs.scale, 0)
+				}
+			}
+		case "Do":
+			if len(args) == 1 && depth < maxFormDepth {
+				if name, ok := args[0].(contentName); ok {
+					if err := e.runForm(resources, string(name), gs, depth); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// runForm interprets the content of a form XObject
+func (e *textExtractor) runForm(resources types.Dict, name string, gs textGraphicsState, depth int) error {
+	xobjects, err := e.ctx.DereferenceDict(resources["XObject"])
+	if err != nil || xobjects == nil {
+		return nil
+	}
+	sd, _, err := e.ctx.DereferenceStreamDict(xobjects[name])
+	if err != nil || sd == nil {
+		return nil
+	}
+	if subtype := sd.Subtype(); subtype == nil || *subtype != "Form" {
+		return nil
+	}
+	if err := sd.Decode(); err != nil {
+		return nil
+	}
+
+	if a, err := e.ctx.DereferenceArray(sd.Dict["Matrix"]); err == nil && len(a) == 6 {
+		var operands []any
+		for _, o := range a {
+			if n := dereferenceNumber(e.ctx, o); n != nil {
+				operands = append(operands, *n)
+			}
+		}
+		if m, ok := matrixOperands(operands); ok {
+			gs.ctm = m.multiply(gs.ctm)
+		}
+	}
+
+	// Forms without resources use those of the page
+	formResources := resources
+	if d, err := e.ctx.DereferenceDict(sd.Dict["Resources"]); err == nil && d != nil {
+		formResources = d
+	}
+
+	return e.run(sd.Content, formResources, gs, depth+1)
+}
+
+// font returns the font named in the resources, loading it on first use
+func (e *textExtractor) font(resources types.Dict, name string, cache map[string]*textFont) *textFont {
+	if f, ok := cache[name]; ok {
+		return f
+	}
+
+	var f *textFont
+	if fonts, err := e.ctx.DereferenceDict(resources["Font"]); err == nil && fonts != nil {
+		ref, indirect := fonts[name].(types.IndirectRef)
+		if indirect {
+			f = e.fonts[ref.ObjectNumber.Value()]
+		}
+		if f == nil {
+			if d, err := e.ctx.DereferenceDict(fonts[name]); err == nil && d != nil {
+				f = loadTextFont(e.ctx, d)
+				if indirect {
+					e.fonts[ref.ObjectNumber.Value()] = f
+				}
+			}
+		}
+	}
+
+	cache[name] = f
+	return f
+}
+
+// show records the text of a shown string and returns the text matrix after it.
+// Spaces follow the glyph positions rather than the string: space characters that
+// character and word spacing shrink to nothing are dropped, and spacing that opens
+// a word-sized gap between glyphs adds one.
+func (e *textExtractor) show(s []byte, tm textMatrix, gs textGraphicsState) textMatrix {
+	if gs.font == nil {
+		return tm
+	}
+
+	var (
+		b          strings.Builder
+		start, end textMatrix
+		gap        float64 // Advance since the end of the last glyph written
+	)
+	for _, g := range gs.font.decode(s) {
+		w := g.width / 1000 * gs.fontSize
+		extra := gs.charSpace
+		if g.space {
+			extra += gs.wordSpace
+		}
+
+		switch {
+		case g.text == "":
+			gap += w + extra
+		case strings.TrimSpace(g.text) == "" && w+extra < wordGap*gs.fontSize:
+			gap += w + extra
+		default:
+			if b.Len() == 0 {
+				start = tm
+			} else if gap > wordGap*gs.fontSize && !endsWithSpace(b.String()) && !startsWithSpace(g.text) {
+				b.WriteString(" ")
+			}
+			b.WriteString(g.text)
+			end = tm.translate(w*gs.scale, 0)
+			gap = extra
+		}
+		tm = tm.translate((w+extra)*gs.scale, 0)
+	}
+
+	if b.Len() == 0 {
+		return tm
+	}
+
+	// Positions and size on the page include the text rise and the transformation matrix
+	rise := textMatrix{1, 0, 0, 1, 0, gs.rise}
+	origin := rise.multiply(start).multiply(gs.ctm)
+	size := gs.fontSize * math.Hypot(origin[2], origin[3])
+	e.addRun(TextRun{
+		Text: b.String(),
+		X:    round2(origin[4]),
+		Y:    round2(origin[5]),
+		Font: gs.font.name,
+		Size: round2(size),
+		endX: rise.multiply(end).multiply(gs.ctm)[4],
+	})
+	return tm
+}
+
+// addRun appends a shown string to the last run if it continues it on the same
+// baseline in the same font, inserting a space for wider gaps
+func (e *textExtractor) addRun(r TextRun) {
+	if n := len(e.runs); n > 0 {
+		last := &e.runs[n-1]
+		gap := r.X - last.endX
+		if last.Font == r.Font && last.Size == r.Size && math.Abs(last.Y-r.Y) < 0.1*r.Size &&
+			gap > -0.5*r.Size && gap < r.Size {
+			if gap > wordGap*r.Size && !endsWithSpace(last.Text) && !startsWithSpace(r.Text) {
+				last.Text += " "
+			}
+			last.Text += r.Text
+			last.endX = r.endX
+			return
+		}
+	}
+	e.runs = append(e.runs, r)
+}
+
+// wordGap is the gap between glyphs, as a fraction of the font size, from which
+// they are taken to belong to different words
+const wordGap = 0.15
+
+// joinTextRuns joins the runs of a page into lines of text
+func joinTextRuns(runs []TextRun) string {
+	var b strings.Builder
+	for i, r := range runs {
+		if i > 0 {
+			prev := runs[i-1]
+			size := math.Max(prev.Size, r.Size)
+			switch {
+			case math.Abs(prev.Y-r.Y) > 0.5*size || r.X < prev.endX-size:
+				b.WriteString("\n")
+			case r.X-prev.endX > wordGap*size && !endsWithSpace(prev.Text) && !startsWithSpace(r.Text):
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString(r.Text)
+	}
+	return tidyLines(b.String())
+}
+
+// tidyLines trims trailing white space from each line and collapses runs of blank
+// lines, which producers leave behind by drawing lone spaces
+func tidyLines(text string) string {
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		line = strings.TrimRightFunc(line, unicode.IsSpace)
+		if line == "" && (len(kept) == 0 || kept[len(kept)-1] == "") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimRight(strings.Join(kept, "\n"), "\n")
+}
+
+// matrixOperands returns six numeric operands as a matrix
+func matrixOperands(args []any) (textMatrix, bool) {
+	if len(args) != 6 {
+		return textMatrix{}, false
+	}
+	var m textMatrix
+	for i, arg := range args {
+		n, ok := arg.(float64)
+		if !ok {
+			return textMatrix{}, false
+		}
+		m[i] = n
+	}
+	return m, true
+}
+
+// numberOperand returns a numeric operand, or 0 for anything else
+func numberOperand(arg any) float64 {
+	n, _ := arg.(float64)
+	return n
+}
+
+// lastNumber returns the last operand as a number
+func lastNumber(args []any) float64 {
+	if len(args) == 0 {
+		return 0
+	}
+	return numberOperand(args[len(args)-1])
+}
+
+// endsWithSpace reports whether s ends in white space
+func endsWithSpace(s string) bool {
+	return strings.TrimRightFunc(s, unicode.IsSpace) != s
+}
+
+// startsWithSpace reports whether s starts with white space
+func startsWithSpace(s string) bool {
+	return strings.TrimLeftFunc(s, unicode.IsSpace) != s
+}
+
+// round2 rounds to two decimal places, enough for positions in points
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,105 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTextPDF writes a one-page PDF that draws content with Helvetica as /F1
+// and returns its path
+func writeTextPDF(t testing.TB, content string) string {
+	t.Helper()
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 5 0 R >> >> /Contents 4 0 R >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	}
+
+	return writeTestPDF(t, objects, "")
+}
+
+// writeTestPDF writes a PDF whose objects are numbered from 1 and whose catalog
+// is the first, with extra entries for its trailer, and returns its path
+func writeTestPDF(t testing.TB, objects []string, trailer string) string {
+	t.Helper()
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(objects))
+	for i, o := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R %s>>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, trailer, xref)
+
+	path := filepath.Join(t.TempDir(), "test.pdf")
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtractText(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"lines", "BT /F1 12 Tf 72 720 Td (Hello) Tj 0 -14 Td (World) Tj ET", "Hello\nWorld"},
+		{"word gap", "BT /F1 12 Tf 72 720 Td (Hello) Tj 40 0 Td (World) Tj ET", "Hello World"},
+		{"kerning", "BT /F1 12 Tf 72 720 Td [(Wo) 80 (rd)] TJ ET", "Word"},
+		{"octal escape", `BT /F1 12 Tf 72 720 Td (caf\351) Tj ET`, "café"},
+		{"no text", "0 0 m 100 100 l S", ""},
+		{"unknown font", "BT /F9 12 Tf 72 720 Td (Hidden) Tj ET", ""},
+		{"text before a syntax error", "BT /F1 12 Tf 72 720 Td (Kept) Tj ET (", "Kept"},
+	}
+
+	for _, tt := range tests {
+		pages, err := ExtractText(writeTextPDF(t, tt.content), TextOptions{})
+		if err != nil {
+			t.Errorf("%s: ExtractText error: %v", tt.name, err)
+			continue
+		}
+		if len(pages) != 1 || pages[0].Text != tt.want {
+			t.Errorf("%s: ExtractText = %+v, want text %q", tt.name, pages, tt.want)
+		}
+	}
+}
+
+func TestExtractTextNestedArrays(t *testing.T) {
+	// Used to overflow the stack and kill the process
+	content := "BT /F1 12 Tf 72 720 Td (Before) Tj ET " + strings.Repeat("[", 1<<20)
+	pages, err := ExtractText(writeTextPDF(t, content), TextOptions{})
+	if err != nil {
+		t.Fatalf("ExtractText error: %v", err)
+	}
+	if len(pages) != 1 || pages[0].Text != "Before" {
+		t.Errorf("ExtractText = %+v, want text %q", pages, "Before")
+	}
+}
+
+func FuzzExtractText(f *testing.F) {
+	f.Add("BT /F1 12 Tf 72 720 Td (Hello) Tj 0 -14 Td (World) Tj ET")
+	f.Add("BT /F1 12 Tf 2 Tz 3 Tc 4 Tw 5 TL T* (a) ' 1 2 (b) \" ET")
+	f.Add("q 1 0 0 1 10 10 cm BT /F1 0 Tf [(A) -1e9 (B)] TJ ET Q")
+	f.Add("BT /F1 12 Tf 0 0 0 0 0 0 Tm (x) Tj ET")
+
+	f.Fuzz(func(t *testing.T, content string) {
+		if strings.Contains(content, "endstream") {
+			t.Skip()
+		}
+		ExtractText(writeTextPDF(t, content), TextOptions{})
+	})
+}
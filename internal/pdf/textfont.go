@@ -0,0 +1,457 @@
+package pdf
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/pdfcpu/pdfcpu/pkg/font"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/unicode/norm"
+)
+
+// textFont decodes the strings shown with a font into text and glyph widths
+type textFont struct {
+	name         string            // BaseFont without a subset prefix
+	codeSpace    []codeRange       // Byte sequences that make up one character code
+	toUnicode    map[uint32]string // Text of character codes from a ToUnicode CMap
+	encoding     *[256]string      // Text of single-byte codes of simple fonts
+	widths       map[uint32]float64
+	defaultWidth float64 // Width of codes without an entry in widths, in thousandths of text space
+	coreFont     string  // Standard 14 font whose metrics stand in for missing widths
+}
+
+// codeRange is a range of character codes of one length, from a CMap's codespace
+type codeRange struct {
+	lo, hi []byte
+}
+
+// textGlyph is one decoded character code
+type textGlyph struct {
+	text  string
+	width float64 // In thousandths of text space
+	space bool    // The single-byte code 32, which word spacing applies to
+}
+
+// loadTextFont reads a font dictionary for text extraction
+func loadTextFont(ctx *model.Context, d types.Dict) *textFont {
+	f := &textFont{defaultWidth: 500}
+	if name := d.NameEntry("BaseFont"); name != nil {
+		f.name = *name
+		if i := strings.IndexByte(f.name, '+'); i == 6 {
+			f.name = f.name[i+1:]
+		}
+	}
+
+	if cmap := dereferenceStreamContent(ctx, d["ToUnicode"]); cmap != nil {
+		f.codeSpace, f.toUnicode = parseToUnicode(cmap)
+	}
+
+	if subtype := d.NameEntry("Subtype"); subtype != nil && *subtype == "Type0" {
+		f.loadCompositeFont(ctx, d)
+	} else {
+		f.loadSimpleFont(ctx, d)
+	}
+	return f
+}
+
+// loadCompositeFont reads the widths of a Type0 font, whose codes are assumed to be
+// two bytes and equal to CIDs unless a ToUnicode CMap says otherwise
+func (f *textFont) loadCompositeFont(ctx *model.Context, d types.Dict) {
+	if len(f.codeSpace) == 0 {
+		f.codeSpace = []codeRange{{lo: []byte{0, 0}, hi: []byte{0xff, 0xff}}}
+	}
+	f.defaultWidth = 1000
+
+	descendants, err := ctx.DereferenceArray(d["DescendantFonts"])
+	if err != nil || len(descendants) == 0 {
+		return
+	}
+	cidFont, err := ctx.DereferenceDict(descendants[0])
+	if err != nil || cidFont == nil {
+		return
+	}
+
+	if dw := dereferenceNumber(ctx, cidFont["DW"]); dw != nil {
+		f.defaultWidth = *dw
+	}
+
+	// W holds "c [w1 w2 ...]" and "cFirst cLast w" entries
+	w, err := ctx.DereferenceArray(cidFont["W"])
+	if err != nil {
+		return
+	}
+	f.widths = map[uint32]float64{}
+	for i := 0; i+1 < len(w); {
+		first := dereferenceNumber(ctx, w[i])
+		if first == nil {
+			return
+		}
+		if list, err := ctx.DereferenceArray(w[i+1]); err == nil && list != nil {
+			for j, o := range list {
+				if width := dereferenceNumber(ctx, o); width != nil {
+					f.widths[uint32(*first)+uint32(j)] = *width
+				}
+			}
+			i += 2
+			continue
+		}
+		if i+2 >= len(w) {
+			return
+		}
+		last, width := dereferenceNumber(ctx, w[i+1]), dereferenceNumber(ctx, w[i+2])
+		if last == nil || width == nil {
+			return
+		}
+		for c := uint32(*first); c <= uint32(*last) && c-uint32(*first) < 0x10000; c++ {
+			f.widths[c] = *width
+		}
+		i += 3
+	}
+}
+
+// loadSimpleFont reads the encoding and widths of a single-byte font
+func (f *textFont) loadSimpleFont(ctx *model.Context, d types.Dict) {
+	f.codeSpace = []codeRange{{lo: []byte{0}, hi: []byte{0xff}}}
+	f.encoding = simpleFontEncoding(ctx, d)
+
+	// Type 3 glyph widths are in glyph space, usually a thousandth of text space
+	scale := 1.0
+	if m, err := ctx.DereferenceArray(d["FontMatrix"]); err == nil && len(m) == 6 {
+		if sx := dereferenceNumber(ctx, m[0]); sx != nil {
+			scale = *sx * 1000
+		}
+	}
+
+	if fd, err := ctx.DereferenceDict(d["FontDescriptor"]); err == nil && fd != nil {
+		if mw := dereferenceNumber(ctx, fd["MissingWidth"]); mw != nil && *mw > 0 {
+			f.defaultWidth = *mw * scale
+		}
+	}
+
+	widths, err := ctx.DereferenceArray(d["Widths"])
+	if err != nil || widths == nil {
+		if font.IsCoreFont(f.name) {
+			f.coreFont = f.name
+		}
+		return
+	}
+
+	first := 0
+	if fc := dereferenceNumber(ctx, d["FirstChar"]); fc != nil {
+		first = int(*fc)
+	}
+	f.widths = map[uint32]float64{}
+	for i, o := range widths {
+		if width := dereferenceNumber(ctx, o); width != nil {
+			f.widths[uint32(first+i)] = *width * scale
+		}
+	}
+}
+
+// decode splits s into character codes and returns their text and widths
+func (f *textFont) decode(s []byte) []textGlyph {
+	var glyphs []textGlyph
+	for len(s) > 0 {
+		n := f.codeLength(s)
+		var code uint32
+		for _, b := range s[:n] {
+			code = code<<8 | uint32(b)
+		}
+
+		g := textGlyph{width: f.width(code), space: n == 1 && code == 32}
+		if text, ok := f.toUnicode[code]; ok {
+			g.text = text
+		} else if f.encoding != nil && code < 256 {
+			g.text = f.encoding[code]
+		}
+		// Codes of composite fonts without a ToUnicode entry cannot be turned into text
+		glyphs = append(glyphs, g)
+		s = s[n:]
+	}
+	return glyphs
+}
+
+// codeLength returns the number of bytes of the character code at the start of s
+func (f *textFont) codeLength(s []byte) int {
+	for _, r := range f.codeSpace {
+		n := len(r.lo)
+		if n == 0 || n > len(s) {
+			continue
+		}
+		match := true
+		for i := 0; i < n; i++ {
+			if s[i] < r.lo[i] || s[i] > r.hi[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return n
+		}
+	}
+	return 1
+}
+
+// width returns the advance of code in thousandths of text space
+func (f *textFont) width(code uint32) float64 {
+	if w, ok := f.widths[code]; ok {
+		return w
+	}
+	if f.coreFont != "" && code < 256 {
+		return float64(font.CharWidth(f.coreFont, rune(code)))
+	}
+	return f.defaultWidth
+}
+
+// maxToUnicodeCodes limits the codes a ToUnicode CMap may map. The largest
+// fonts have 65,535 glyphs, while a few bytes of ranges can name billions of codes.
+const maxToUnicodeCodes = 1 << 20
+
+// parseToUnicode reads the codespace ranges and mappings of a ToUnicode CMap
+func parseToUnicode(data []byte) ([]codeRange, map[uint32]string) {
+	ops, _ := parseContent(data)
+
+	var codeSpace []codeRange
+	m := map[uint32]string{}
+	for _, op := range ops {
+		switch op.name {
+		case "endcodespacerange":
+			for i := 0; i+1 < len(op.operands); i += 2 {
+				lo, ok1 := op.operands[i].([]byte)
+				hi, ok2 := op.operands[i+1].([]byte)
+				if ok1 && ok2 && len(lo) == len(hi) && len(lo) > 0 && len(lo) <= 4 {
+					codeSpace = append(codeSpace, codeRange{lo: lo, hi: hi})
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(op.operands); i += 2 {
+				src, ok1 := op.operands[i].([]byte)
+				dst, ok2 := op.operands[i+1].([]byte)
+				if ok1 && ok2 && len(src) <= 4 {
+					m[codeValue(src)] = utf16Text(dst)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(op.operands); i += 3 {
+				lo, ok1 := op.operands[i].([]byte)
+				hi, ok2 := op.operands[i+1].([]byte)
+				if !ok1 || !ok2 || len(lo) > 4 || len(hi) > 4 {
+					continue
+				}
+				first, last := codeValue(lo), codeValue(hi)
+				if last < first || last-first > 0xffff || len(m)+int(last-first) >= maxToUnicodeCodes {
+					continue
+				}
+				switch dst := op.operands[i+2].(type) {
+				case []byte:
+					// Consecutive codes map to consecutive values of the last UTF-16 unit
+					units := utf16Units(dst)
+					if len(units) == 0 {
+						continue
+					}
+					for c := first; c <= last; c++ {
+						next := append([]uint16(nil), units...)
+						next[len(next)-1] += uint16(c - first)
+						m[c] = string(utf16.Decode(next))
+					}
+				case []any:
+					for j, o := range dst {
+						if s, ok := o.([]byte); ok && first+uint32(j) <= last {
+							m[first+uint32(j)] = utf16Text(s)
+						}
+					}
+				}
+			}
+		}
+	}
+	return codeSpace, m
+}
+
+// codeValue returns a character code given as big-endian bytes
+func codeValue(b []byte) uint32 {
+	var v uint32
+	for _, c := range b {
+		v = v<<8 | uint32(c)
+	}
+	return v
+}
+
+// utf16Units splits big-endian UTF-16 bytes into code units
+func utf16Units(b []byte) []uint16 {
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+	}
+	return units
+}
+
+// utf16Text decodes big-endian UTF-16 bytes
+func utf16Text(b []byte) string {
+	if len(b) == 1 {
+		return string(rune(b[0]))
+	}
+	return string(utf16.Decode(utf16Units(b)))
+}
+
+// simpleFontEncoding returns the text of each code of a single-byte font from its
+// Encoding entry. Fonts without one are assumed to use WinAnsiEncoding, which
+// matches StandardEncoding for letters, digits and common punctuation.
+func simpleFontEncoding(ctx *model.Context, d types.Dict) *[256]string {
+	base := "WinAnsiEncoding"
+	var differences types.Array
+
+	switch enc := dereferenceObject(ctx, d["Encoding"]).(type) {
+	case types.Name:
+		base = string(enc)
+	case types.Dict:
+		if name := enc.NameEntry("BaseEncoding"); name != nil {
+			base = *name
+		}
+		differences, _ = ctx.DereferenceArray(enc["Differences"])
+	}
+
+	cm := charmap.Windows1252
+	if base == "MacRomanEncoding" {
+		cm = charmap.Macintosh
+	}
+
+	var encoding [256]string
+	for c := 32; c < 256; c++ {
+		if r := cm.DecodeByte(byte(c)); r != '\ufffd' {
+			encoding[c] = string(r)
+		}
+	}
+	// Control codes carry no text in WinAnsiEncoding
+	encoding[0x7f] = ""
+
+	// Differences lists a code followed by the glyph names of it and the codes after it
+	code := 0
+	for _, o := range differences {
+		switch v := dereferenceObject(ctx, o).(type) {
+		case types.Integer:
+			code = v.Value()
+		case types.Float:
+			code = int(v.Value())
+		case types.Name:
+			if code >= 0 && code < 256 {
+				encoding[code] = glyphText(string(v))
+			}
+			code++
+		}
+	}
+
+	return &encoding
+}
+
+// glyphNames maps glyph names that are not a single character or a uniXXXX name
+// to their text. Accented letters like "eacute" are composed in glyphText.
+var glyphNames = map[string]string{
+	"space": " ", "exclam": "!", "quotedbl": "\"", "numbersign": "#", "dollar": "$", "percent": "%",
+	"ampersand": "&", "quotesingle": "'", "quoteright": "’", "parenleft": "(", "parenright": ")",
+	"asterisk": "*", "plus": "+", "comma": ",", "hyphen": "-", "period": ".", "slash": "/",
+	"zero": "0", "one": "1", "two": "2", "three": "3", "four": "4", "five": "5", "six": "6",
+	"seven": "7", "eight": "8", "nine": "9", "colon": ":", "semicolon": ";", "less": "<",
+	"equal": "=", "greater": ">", "question": "?", "at": "@", "bracketleft": "[", "backslash": "\\",
+	"bracketright": "]", "asciicircum": "^", "underscore": "_", "grave": "`", "quoteleft": "‘",
+	"braceleft": "{", "bar": "|", "braceright": "}", "asciitilde": "~", "exclamdown": "¡",
+	"cent": "¢", "sterling": "£", "yen": "¥", "Euro": "€", "section": "§", "paragraph": "¶",
+	"bullet": "•", "endash": "–", "emdash": "—", "ellipsis": "…", "quotedblleft": "“",
+	"quotedblright": "”", "quotesinglbase": "‚", "quotedblbase": "„", "guillemotleft": "«",
+	"guillemotright": "»", "guilsinglleft": "‹", "guilsinglright": "›", "dagger": "†",
+	"daggerdbl": "‡", "perthousand": "‰", "trademark": "™", "copyright": "©", "registered": "®",
+	"degree": "°", "plusminus": "±", "multiply": "×", "divide": "÷", "minus": "−",
+	"questiondown": "¿", "germandbls": "ß", "ae": "æ", "AE": "Æ", "oe": "œ", "OE": "Œ",
+	"oslash": "ø", "Oslash": "Ø", "lslash": "ł", "Lslash": "Ł", "dotlessi": "ı", "eth": "ð",
+	"Eth": "Ð", "thorn": "þ", "Thorn": "Þ", "fi": "fi", "fl": "fl", "ff": "ff", "ffi": "ffi",
+	"ffl": "ffl", "nbspace": "\u00a0", "nonbreakingspace": "\u00a0", "sfthyphen": "\u00ad",
+	"periodcentered": "·", "onehalf": "½", "onequarter": "¼", "threequarters": "¾",
+	"mu": "µ", "ordfeminine": "ª", "ordmasculine": "º", "logicalnot": "¬", "florin": "ƒ",
+}
+
+// glyphAccents maps accent suffixes of glyph names to combining characters
+var glyphAccents = map[string]string{
+	"acute": "\u0301", "grave": "\u0300", "circumflex": "\u0302", "dieresis": "\u0308",
+	"tilde": "\u0303", "ring": "\u030a", "cedilla": "\u0327", "caron": "\u030c",
+	"macron": "\u0304", "breve": "\u0306", "dotaccent": "\u0307", "ogonek": "\u0328",
+	"hungarumlaut": "\u030b",
+}
+
+// glyphText returns the text of a glyph name, or "" for names it does not know
+func glyphText(name string) string {
+	// Suffixes like ".sc" or "_alt" name variants of the same character
+	if i := strings.IndexAny(name, "._"); i > 0 {
+		name = name[:i]
+	}
+
+	if len(name) == 1 {
+		return name
+	}
+	if s, ok := glyphNames[name]; ok {
+		return s
+	}
+
+	if strings.HasPrefix(name, "uni") && len(name) >= 7 && (len(name)-3)%4 == 0 {
+		var units []uint16
+		for i := 3; i < len(name); i += 4 {
+			v, err := strconv.ParseUint(name[i:i+4], 16, 16)
+			if err != nil {
+				return ""
+			}
+			units = append(units, uint16(v))
+		}
+		return string(utf16.Decode(units))
+	}
+	if strings.HasPrefix(name, "u") && len(name) >= 5 && len(name) <= 7 {
+		if v, err := strconv.ParseUint(name[1:], 16, 32); err == nil {
+			return string(rune(v))
+		}
+	}
+
+	if accent, ok := glyphAccents[name[1:]]; ok {
+		return norm.NFC.String(name[:1] + accent)
+	}
+	return ""
+}
+
+// dereferenceStreamContent returns the decoded content of a stream, or nil
+func dereferenceStreamContent(ctx *model.Context, o types.Object) []byte {
+	if o == nil {
+		return nil
+	}
+	sd, _, err := ctx.DereferenceStreamDict(o)
+	if err != nil || sd == nil {
+		return nil
+	}
+	if err := sd.Decode(); err != nil {
+		return nil
+	}
+	return sd.Content
+}
+
+// dereferenceNumber returns an integer or real number, or nil
+func dereferenceNumber(ctx *model.Context, o types.Object) *float64 {
+	switch v := dereferenceObject(ctx, o).(type) {
+	case types.Integer:
+		f := float64(v.Value())
+		return &f
+	case types.Float:
+		f := v.Value()
+		return &f
+	}
+	return nil
+}
+
+// dereferenceObject resolves indirect references, returning nil for broken ones
+func dereferenceObject(ctx *model.Context, o types.Object) types.Object {
+	if o == nil {
+		return nil
+	}
+	o, err := ctx.Dereference(o)
+	if err != nil {
+		return nil
+	}
+	return o
+}
@@ -0,0 +1,99 @@
+package pdf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseToUnicode(t *testing.T) {
+	cmap := `/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+/CMapName /Test def
+2 begincodespacerange
+<00> <7F>
+<8000> <FFFF>
+endcodespacerange
+3 beginbfchar
+<01> <0041>
+<02> <D83DDE00>
+<8001> <00660069>
+endbfchar
+2 beginbfrange
+<10> <12> <0061>
+<20> <21> [<0058> <0059>]
+endbfrange
+endcmap
+CMapName currentdict /CMap defineresource pop
+end
+end`
+
+	codeSpace, m := parseToUnicode([]byte(cmap))
+
+	wantSpace := []codeRange{
+		{lo: []byte{0x00}, hi: []byte{0x7f}},
+		{lo: []byte{0x80, 0x00}, hi: []byte{0xff, 0xff}},
+	}
+	if !reflect.DeepEqual(codeSpace, wantSpace) {
+		t.Errorf("code space = %v, want %v", codeSpace, wantSpace)
+	}
+
+	want := map[uint32]string{
+		0x01:   "A",
+		0x02:   "😀",
+		0x8001: "fi",
+		0x10:   "a",
+		0x11:   "b",
+		0x12:   "c",
+		0x20:   "X",
+		0x21:   "Y",
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("mappings = %v, want %v", m, want)
+	}
+}
+
+func TestParseToUnicodeLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		cmap string
+		max  int
+	}{
+		{"reversed range", "1 beginbfrange <20> <10> <0041> endbfrange", 0},
+		{"range over 65536 codes", "1 beginbfrange <000000> <FFFFFF> <0041> endbfrange", 0},
+		{"codes longer than 4 bytes", "1 beginbfchar <0102030405> <0041> endbfchar", 0},
+		// Every range is allowed on its own, but together they would name
+		// billions of codes
+		{"too many codes", manyRanges(100), maxToUnicodeCodes},
+	}
+
+	for _, tt := range tests {
+		_, m := parseToUnicode([]byte(tt.cmap))
+		if len(m) > tt.max {
+			t.Errorf("%s: %d mappings, want at most %d", tt.name, len(m), tt.max)
+		}
+	}
+}
+
+// manyRanges returns bfrange operators for n distinct blocks of 65,536 codes
+func manyRanges(n int) string {
+	var sb strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&sb, "1 beginbfrange <%04X0000> <%04XFFFF> <0041> endbfrange\n", i, i)
+	}
+	return sb.String()
+}
+
+func FuzzParseToUnicode(f *testing.F) {
+	f.Add([]byte("1 begincodespacerange <0000> <FFFF> endcodespacerange"))
+	f.Add([]byte("2 beginbfchar <01> <0041> <02> <D83DDE00> endbfchar"))
+	f.Add([]byte("1 beginbfrange <10> <12> <0061> endbfrange"))
+	f.Add([]byte("1 beginbfrange <20> <21> [<0058> <0059>] endbfrange"))
+	f.Add([]byte("1 beginbfrange <FFFF> <FFFF> <> endbfrange"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		parseToUnicode(data)
+	})
+}
@@ -50,6 +50,21 @@ func (s *Server) Start() error {
 	mux.HandleFunc("/add-password", handlers.AddPasswordPage)
 	mux.HandleFunc("/remove-page", handlers.RemovePagePage)
 	mux.HandleFunc("/image-to-pdf", handlers.ImageToPDFPage)
+	mux.HandleFunc("/rotate", handlers.RotatePage)
+	mux.HandleFunc("/organize", handlers.OrganizePage)
+	mux.HandleFunc("/watermark", handlers.WatermarkPage)
+	mux.HandleFunc("/page-numbers", handlers.PageNumbersPage)
+	mux.HandleFunc("/bates", handlers.BatesPage)
+	mux.HandleFunc("/insert", handlers.InsertPage)
+	mux.HandleFunc("/metadata", handlers.MetadataPage)
+	mux.HandleFunc("/change-password", handlers.ChangePasswordPage)
+	mux.HandleFunc("/extract-images", handlers.ExtractImagesPage)
+	mux.HandleFunc("/extract-text", handlers.ExtractTextPage)
+	mux.HandleFunc("/attachments", handlers.AttachmentsPage)
+	mux.HandleFunc("/form", handlers.FormPage)
+	mux.HandleFunc("/flatten", handlers.FlattenPage)
+	mux.HandleFunc("/annotations", handlers.AnnotationsPage)
+	mux.HandleFunc("/bookmarks", handlers.BookmarksPage)
 
 	// API routes
 	mux.HandleFunc("/api/split", handlers.HandleSplit(s.tmpDir, s.maxMemory))
@@ -61,6 +76,24 @@ func (s *Server) Start() error {
 	mux.HandleFunc("/api/add-password", handlers.HandleAddPassword(s.tmpDir, s.maxMemory))
 	mux.HandleFunc("/api/remove-page", handlers.HandleRemovePage(s.tmpDir, s.maxMemory))
 	mux.HandleFunc("/api/image-to-pdf", handlers.HandleImageToPDF(s.tmpDir, s.maxMemory))
+	mux.HandleFunc("/api/rotate", handlers.HandleRotate(s.tmpDir, s.maxMemory))
+	mux.HandleFunc("/api/organize", handlers.HandleOrganize(s.tmpDir, s.maxMemory))
+	mux.HandleFunc("/api/page-count", handlers.HandlePageCount(s.tmpDir, s.maxMemory))
+	mux.HandleFunc("/api/watermark", handlers.HandleWatermark(s.tmpDir, s.maxMemory))
+	mux.HandleFunc("/api/page-numbers", handlers.HandlePageNumbers(s.tmpDir, s.maxMemory))
+	mux.HandleFunc("/api/bates", handlers.HandleBates(s.tmpDir, s.maxMemory))
+	mux.HandleFunc("/api/insert", handlers.HandleInsert(s.tmpDir, s.maxMemory))
+	mux.HandleFunc("/api/metadata", handlers.HandleMetadata(s.tmpDir, s.maxMemory))
+	mux.HandleFunc("/api/info", handlers.HandleInfo(s.tmpDir, s.maxMemory))
+	mux.HandleFunc("/api/change-password", handlers.HandleChangePassword(s.tmpDir, s.maxMemory))
+	mux.HandleFunc("/api/extract-images", handlers.HandleExtractImages(s.tmpDir, s.maxMemory))
+	mux.HandleFunc("/api/extract-text", handlers.HandleExtractText(s.tmpDir, s.maxMemory))
+	mux.HandleFunc("/api/attachments", handlers.HandleAttachments(s.tmpDir, s.maxMemory))
+	mux.HandleFunc("/api/form/fields", handlers.HandleFormFields(s.tmpDir, s.maxMemory))
+	mux.HandleFunc("/api/form/fill", handlers.HandleFormFill(s.tmpDir, s.maxMemory))
+	mux.HandleFunc("/api/flatten", handlers.HandleFlatten(s.tmpDir, s.maxMemory))
+	mux.HandleFunc("/api/annotations", handlers.HandleAnnotations(s.tmpDir, s.maxMemory))
+	mux.HandleFunc("/api/bookmarks", handlers.HandleBookmarks(s.tmpDir, s.maxMemory))
 	mux.HandleFunc("/download/", handlers.HandleDownload(s.tmpDir))
 
 	// Wrap with middleware
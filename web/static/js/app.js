@@ -33,6 +33,36 @@ document.addEventListener('DOMContentLoaded', function() {
         initRemovePagePage();
     } else if (document.getElementById('imageToPDFForm')) {
         initImageToPDFPage();
+    } else if (document.getElementById('rotateForm')) {
+        initRotatePage();
+    } else if (document.getElementById('organizeForm')) {
+        initOrganizePage();
+    } else if (document.getElementById('watermarkForm')) {
+        initWatermarkPage();
+    } else if (document.getElementById('pageNumbersForm')) {
+        initPageNumbersPage();
+    } else if (document.getElementById('batesForm')) {
+        initBatesPage();
+    } else if (document.getElementById('insertForm')) {
+        initInsertPage();
+    } else if (document.getElementById('metadataForm')) {
+        initMetadataPage();
+    } else if (document.getElementById('changePasswordForm')) {
+        initChangePasswordPage();
+    } else if (document.getElementById('extractImagesForm')) {
+        initExtractImagesPage();
+    } else if (document.getElementById('extractTextForm')) {
+        initExtractTextPage();
+    } else if (document.getElementById('attachmentsForm')) {
+        initAttachmentsPage();
+    } else if (document.getElementById('formFillForm')) {
+        initFormPage();
+    } else if (document.getElementById('flattenForm')) {
+        initFlattenPage();
+    } else if (document.getElementById('annotationsForm')) {
+        initAnnotationsPage();
+    } else if (document.getElementById('bookmarksForm')) {
+        initBookmarksPage();
     }
 });
 
@@ -105,16 +135,60 @@ function showResult(message, isError = false, downloadUrl = null) {
     hideProgress();
 }
 
+// Drag and drop reordering for lists rendered from an array.
+// Each draggable child needs a data-index; render is called after a move.
+function enableDragReorder(container, items, render) {
+    let draggedIndex;
+
+    container.querySelectorAll('[draggable="true"]').forEach(item => {
+        item.addEventListener('dragstart', function(e) {
+            draggedIndex = parseInt(e.currentTarget.dataset.index);
+            e.currentTarget.classList.add('dragging');
+        });
+
+        item.addEventListener('dragover', function(e) {
+            e.preventDefault();
+        });
+
+        item.addEventListener('drop', function(e) {
+            e.preventDefault();
+            const dropIndex = parseInt(e.currentTarget.dataset.index);
+
+            if (draggedIndex !== dropIndex) {
+                const draggedItem = items[draggedIndex];
+                items.splice(draggedIndex, 1);
+                items.splice(dropIndex, 0, draggedItem);
+                render();
+            }
+        });
+
+        item.addEventListener('dragend', function(e) {
+            e.currentTarget.classList.remove('dragging');
+        });
+    });
+}
+
 // Split page
 function initSplitPage() {
     const form = document.getElementById('splitForm');
     const rangeRadio = document.getElementById('customRange');
     const pageRangeInput = document.getElementById('pageRange');
-
-    // Enable/disable page range input
+    const groupsRadio = document.getElementById('rangeGroups');
+    const pageGroupsInput = document.getElementById('pageGroups');
+    const partNamesInput = document.getElementById('partNames');
+    const everyRadio = document.getElementById('everyPages');
+    const everyInput = document.getElementById('every');
+    const sizeRadio = document.getElementById('bySize');
+    const maxSizeInput = document.getElementById('maxSizeMB');
+
+    // Enable only the input that belongs to the selected mode
     document.querySelectorAll('input[name="splitMode"]').forEach(radio => {
         radio.addEventListener('change', function() {
             pageRangeInput.disabled = !rangeRadio.checked;
+            pageGroupsInput.disabled = !groupsRadio.checked;
+            partNamesInput.disabled = !groupsRadio.checked;
+            everyInput.disabled = !everyRadio.checked;
+            maxSizeInput.disabled = !sizeRadio.checked;
         });
     });
 
@@ -129,6 +203,11 @@ function initSplitPage() {
             return;
         }
 
+        if (splitMode === 'groups' && !formData.get('pageGroups')) {
+            showResult('Please enter page groups', true);
+            return;
+        }
+
         showProgress();
 
         try {
@@ -159,7 +238,7 @@ function initMergePage() {
     let selectedFiles = [];
 
     fileInput.addEventListener('change', function(e) {
-        selectedFiles = Array.from(e.target.files);
+        selectedFiles = Array.from(e.target.files).map(file => ({ file: file, range: '', keepBookmarks: true }));
         updateFileList();
     });
 
@@ -173,21 +252,33 @@ function initMergePage() {
         fileList.style.display = 'block';
         document.getElementById('submitBtn').disabled = false;
 
-        fileItems.innerHTML = selectedFiles.map((file, index) => `
+        fileItems.innerHTML = selectedFiles.map((entry, index) => `
             <div class="file-item" draggable="true" data-index="${index}">
-                <span class="file-item-name">${index + 1}. ${file.name}</span>
+                <span class="file-item-name">${index + 1}. ${entry.file.name}</span>
+                <input type="text" class="file-item-range" data-index="${index}" placeholder="All pages (e.g., 1-3,last)">
+                <label class="file-item-bookmarks"><input type="checkbox" data-index="${index}"> Keep bookmarks</label>
                 <span class="file-item-remove" onclick="removeFile(${index})">✕</span>
             </div>
         `).join('');
 
-        // Add drag and drop for reordering
-        const items = fileItems.querySelectorAll('.file-item');
-        items.forEach(item => {
-            item.addEventListener('dragstart', handleDragStart);
-            item.addEventListener('dragover', handleDragOverItem);
-            item.addEventListener('drop', handleDropItem);
-            item.addEventListener('dragend', handleDragEnd);
+        // Keep each file's page range and bookmark choice with the file when the list is reordered
+        fileItems.querySelectorAll('.file-item-range').forEach(input => {
+            const entry = selectedFiles[parseInt(input.dataset.index)];
+            input.value = entry.range;
+            input.addEventListener('input', function() {
+                entry.range = input.value;
+            });
+        });
+        fileItems.querySelectorAll('.file-item-bookmarks input').forEach(input => {
+            const entry = selectedFiles[parseInt(input.dataset.index)];
+            input.checked = entry.keepBookmarks;
+            input.addEventListener('change', function() {
+                entry.keepBookmarks = input.checked;
+            });
         });
+
+        // Add drag and drop for reordering
+        enableDragReorder(fileItems, selectedFiles, updateFileList);
     }
 
     window.removeFile = function(index) {
@@ -195,32 +286,13 @@ function initMergePage() {
         updateFileList();
     };
 
-    let draggedIndex;
-
-    function handleDragStart(e) {
-        draggedIndex = parseInt(e.currentTarget.dataset.index);
-        e.currentTarget.classList.add('dragging');
-    }
-
-    function handleDragOverItem(e) {
-        e.preventDefault();
-    }
-
-    function handleDropItem(e) {
-        e.preventDefault();
-        const dropIndex = parseInt(e.currentTarget.dataset.index);
-
-        if (draggedIndex !== dropIndex) {
-            const draggedFile = selectedFiles[draggedIndex];
-            selectedFiles.splice(draggedIndex, 1);
-            selectedFiles.splice(dropIndex, 0, draggedFile);
-            updateFileList();
-        }
-    }
-
-    function handleDragEnd(e) {
-        e.currentTarget.classList.remove('dragging');
-    }
+    const interleaveRadio = document.getElementById('interleaveMode');
+    const reverseSecond = document.getElementById('reverseSecond');
+    document.querySelectorAll('input[name="mergeMode"]').forEach(radio => {
+        radio.addEventListener('change', function() {
+            reverseSecond.disabled = !interleaveRadio.checked;
+        });
+    });
 
     form.addEventListener('submit', async function(e) {
         e.preventDefault();
@@ -230,11 +302,19 @@ function initMergePage() {
             return;
         }
 
+        if (interleaveRadio.checked && selectedFiles.length !== 2) {
+            showResult('Interleaving needs exactly 2 PDF files: fronts first, then backs', true);
+            return;
+        }
+
         showProgress();
 
-        const formData = new FormData();
-        selectedFiles.forEach(file => {
-            formData.append('files', file);
+        const formData = new FormData(form);
+        formData.delete('files');
+        selectedFiles.forEach(entry => {
+            formData.append('files', entry.file);
+            formData.append('ranges[]', entry.range.trim());
+            formData.append('keepBookmarks[]', entry.keepBookmarks ? 'true' : 'false');
         });
 
         try {
@@ -281,6 +361,12 @@ function initCompressPage() {
                     const reduction = ((1 - data.compressedSize / data.originalSize) * 100).toFixed(1);
                     message += ` Size reduced by ${reduction}%`;
                 }
+                if (data.steps && data.steps.length > 0) {
+                    message += '<br>' + data.steps.map(step => {
+                        const savedKB = (step.bytesSaved / 1024).toFixed(1);
+                        return `${step.name}: ${savedKB} KB saved`;
+                    }).join('<br>');
+                }
                 showResult(message, false, data.downloadUrl);
             } else {
                 showResult(data.error || 'Compression failed', true);
@@ -481,17 +567,11 @@ function initCompressGIFPage() {
 // Remove password page
 function initRemovePasswordPage() {
     const form = document.getElementById('removePasswordForm');
-    const passwordInput = document.getElementById('passwordInput');
 
+    // The password may be empty for PDFs that open without one but restrict printing or copying
     form.addEventListener('submit', async function(e) {
         e.preventDefault();
 
-        const password = passwordInput.value.trim();
-        if (!password) {
-            showResult('Please enter the PDF password', true);
-            return;
-        }
-
         showProgress();
 
         const formData = new FormData(form);
@@ -516,15 +596,31 @@ function initRemovePasswordPage() {
 }
 
 // Add password page
+// Offer the key lengths of the selected encryption algorithm
+function initEncryptionOptions() {
+    const algorithmSelect = document.getElementById('algorithm');
+    const keyLengthSelect = document.getElementById('keyLength');
+
+    // AES uses 128 or 256-bit keys, RC4 40 or 128-bit keys
+    const keyLengths = { AES: ['256', '128'], RC4: ['128', '40'] };
+    algorithmSelect.addEventListener('change', function() {
+        keyLengthSelect.innerHTML = keyLengths[this.value]
+            .map(length => `<option value="${length}">${length}-bit</option>`)
+            .join('');
+    });
+}
+
 function initAddPasswordPage() {
     const form = document.getElementById('addPasswordForm');
     const passwordInput = document.getElementById('passwordInput');
+    const ownerPasswordInput = document.getElementById('ownerPasswordInput');
+
+    initEncryptionOptions();
 
     form.addEventListener('submit', async function(e) {
         e.preventDefault();
 
-        const password = passwordInput.value.trim();
-        if (!password) {
+        if (!passwordInput.value.trim() && !ownerPasswordInput.value.trim()) {
             showResult('Please enter a password', true);
             return;
         }
@@ -620,13 +716,7 @@ function initImageToPDFPage() {
         `).join('');
 
         // Add drag and drop for reordering
-        const items = fileItems.querySelectorAll('.file-item');
-        items.forEach(item => {
-            item.addEventListener('dragstart', handleDragStart);
-            item.addEventListener('dragover', handleDragOverItem);
-            item.addEventListener('drop', handleDropItem);
-            item.addEventListener('dragend', handleDragEnd);
-        });
+        enableDragReorder(fileItems, selectedFiles, updateFileList);
     }
 
     window.removeImageFile = function(index) {
@@ -634,50 +724,238 @@ function initImageToPDFPage() {
         updateFileList();
     };
 
-    let draggedIndex;
+    form.addEventListener('submit', async function(e) {
+        e.preventDefault();
 
-    function handleDragStart(e) {
-        draggedIndex = parseInt(e.currentTarget.dataset.index);
-        e.currentTarget.classList.add('dragging');
-    }
+        if (selectedFiles.length === 0) {
+            showResult('Please select at least 1 image file', true);
+            return;
+        }
 
-    function handleDragOverItem(e) {
-        e.preventDefault();
-    }
+        showProgress();
+
+        const formData = new FormData();
+        selectedFiles.forEach(file => {
+            formData.append('files', file);
+        });
+
+        try {
+            const response = await fetch('/api/image-to-pdf', {
+                method: 'POST',
+                body: formData
+            });
+
+            const data = await response.json();
+
+            if (response.ok) {
+                showResult(data.message, false, data.downloadUrl);
+            } else {
+                showResult(data.error || 'Conversion failed', true);
+            }
+        } catch (error) {
+            showResult('Network error: ' + error.message, true);
+        }
+    });
+}
+
+// Rotate page
+function initRotatePage() {
+    const form = document.getElementById('rotateForm');
 
-    function handleDropItem(e) {
+    form.addEventListener('submit', async function(e) {
         e.preventDefault();
-        const dropIndex = parseInt(e.currentTarget.dataset.index);
 
-        if (draggedIndex !== dropIndex) {
-            const draggedFile = selectedFiles[draggedIndex];
-            selectedFiles.splice(draggedIndex, 1);
-            selectedFiles.splice(dropIndex, 0, draggedFile);
-            updateFileList();
+        showProgress();
+
+        const formData = new FormData(form);
+
+        try {
+            const response = await fetch('/api/rotate', {
+                method: 'POST',
+                body: formData
+            });
+
+            const data = await response.json();
+
+            if (response.ok) {
+                showResult(data.message, false, data.downloadUrl);
+            } else {
+                showResult(data.error || 'Failed to rotate pages', true);
+            }
+        } catch (error) {
+            showResult('Network error: ' + error.message, true);
+        }
+    });
+}
+
+// Organize page
+function initOrganizePage() {
+    const form = document.getElementById('organizeForm');
+    const fileInput = document.getElementById('fileInput');
+    const pageList = document.getElementById('pageList');
+    const pageTiles = document.getElementById('pageTiles');
+    const resetBtn = document.getElementById('resetPagesBtn');
+    let pageCount = 0;
+    let pages = [];
+
+    fileInput.addEventListener('change', async function(e) {
+        const file = e.target.files[0];
+        pages = [];
+        updatePageTiles();
+        if (!file) {
+            return;
+        }
+
+        const formData = new FormData();
+        formData.append('file', file);
+
+        try {
+            const response = await fetch('/api/page-count', {
+                method: 'POST',
+                body: formData
+            });
+
+            const data = await response.json();
+
+            if (response.ok) {
+                pageCount = data.pageCount;
+                resetPages();
+            } else {
+                showResult(data.error || 'Failed to read PDF', true);
+            }
+        } catch (error) {
+            showResult('Network error: ' + error.message, true);
         }
+    });
+
+    function resetPages() {
+        pages = Array.from({ length: pageCount }, (_, i) => i + 1);
+        updatePageTiles();
     }
 
-    function handleDragEnd(e) {
-        e.currentTarget.classList.remove('dragging');
+    function updatePageTiles() {
+        if (pages.length === 0) {
+            pageList.style.display = pageCount > 0 ? 'block' : 'none';
+            pageTiles.innerHTML = '';
+            document.getElementById('submitBtn').disabled = true;
+            return;
+        }
+
+        pageList.style.display = 'block';
+        document.getElementById('submitBtn').disabled = false;
+
+        pageTiles.innerHTML = pages.map((page, index) => `
+            <div class="page-tile" draggable="true" data-index="${index}">
+                <span class="page-tile-number">Page ${page}</span>
+                <span class="page-tile-actions">
+                    <span title="Duplicate" onclick="duplicatePage(${index})">⧉</span>
+                    <span class="file-item-remove" title="Remove" onclick="removePage(${index})">✕</span>
+                </span>
+            </div>
+        `).join('');
+
+        // Reuse the merge list's drag and drop reordering for the tiles
+        enableDragReorder(pageTiles, pages, updatePageTiles);
     }
 
+    window.duplicatePage = function(index) {
+        pages.splice(index + 1, 0, pages[index]);
+        updatePageTiles();
+    };
+
+    window.removePage = function(index) {
+        pages.splice(index, 1);
+        updatePageTiles();
+    };
+
+    resetBtn.addEventListener('click', resetPages);
+
     form.addEventListener('submit', async function(e) {
         e.preventDefault();
 
-        if (selectedFiles.length === 0) {
-            showResult('Please select at least 1 image file', true);
+        if (pages.length === 0) {
+            showResult('Please keep at least one page', true);
             return;
         }
 
         showProgress();
 
         const formData = new FormData();
-        selectedFiles.forEach(file => {
-            formData.append('files', file);
+        formData.append('file', fileInput.files[0]);
+        formData.append('order', pages.join(','));
+
+        try {
+            const response = await fetch('/api/organize', {
+                method: 'POST',
+                body: formData
+            });
+
+            const data = await response.json();
+
+            if (response.ok) {
+                showResult(data.message, false, data.downloadUrl);
+            } else {
+                showResult(data.error || 'Failed to organize pages', true);
+            }
+        } catch (error) {
+            showResult('Network error: ' + error.message, true);
+        }
+    });
+}
+
+// Watermark page
+function initWatermarkPage() {
+    const form = document.getElementById('watermarkForm');
+    const textOptions = document.getElementById('textOptions');
+    const imageOptions = document.getElementById('imageOptions');
+    const imageInput = document.getElementById('imageInput');
+
+    // Update slider value displays
+    const sliders = [
+        { input: 'scale', display: 'scaleValue' },
+        { input: 'rotation', display: 'rotationValue' },
+        { input: 'opacity', display: 'opacityValue' }
+    ];
+
+    sliders.forEach(({ input, display }) => {
+        const slider = document.getElementById(input);
+        const displayEl = document.getElementById(display);
+        if (slider && displayEl) {
+            slider.addEventListener('input', function() {
+                displayEl.textContent = this.value;
+            });
+        }
+    });
+
+    // Toggle text and image options
+    document.querySelectorAll('input[name="watermarkType"]').forEach(radio => {
+        radio.addEventListener('change', function() {
+            const isImage = this.value === 'image';
+            textOptions.style.display = isImage ? 'none' : 'block';
+            imageOptions.style.display = isImage ? 'block' : 'none';
         });
+    });
+
+    form.addEventListener('submit', async function(e) {
+        e.preventDefault();
+
+        const formData = new FormData(form);
+        const watermarkType = formData.get('watermarkType');
+
+        if (watermarkType === 'image' && imageInput.files.length === 0) {
+            showResult('Please choose a watermark image', true);
+            return;
+        }
+
+        if (watermarkType === 'text' && !formData.get('text').trim()) {
+            showResult('Please enter the watermark text', true);
+            return;
+        }
+
+        showProgress();
 
         try {
-            const response = await fetch('/api/image-to-pdf', {
+            const response = await fetch('/api/watermark', {
                 method: 'POST',
                 body: formData
             });
@@ -687,7 +965,1023 @@ function initImageToPDFPage() {
             if (response.ok) {
                 showResult(data.message, false, data.downloadUrl);
             } else {
-                showResult(data.error || 'Conversion failed', true);
+                showResult(data.error || 'Failed to add watermark', true);
+            }
+        } catch (error) {
+            showResult('Network error: ' + error.message, true);
+        }
+    });
+}
+
+// Page numbers page
+function initPageNumbersPage() {
+    const form = document.getElementById('pageNumbersForm');
+
+    form.addEventListener('submit', async function(e) {
+        e.preventDefault();
+
+        const formData = new FormData(form);
+
+        if (!formData.get('format').trim() && !formData.get('header').trim() && !formData.get('footer').trim()) {
+            showResult('Please enter a page number format, header or footer', true);
+            return;
+        }
+
+        showProgress();
+
+        try {
+            const response = await fetch('/api/page-numbers', {
+                method: 'POST',
+                body: formData
+            });
+
+            const data = await response.json();
+
+            if (response.ok) {
+                showResult(data.message, false, data.downloadUrl);
+            } else {
+                showResult(data.error || 'Failed to add page numbers', true);
+            }
+        } catch (error) {
+            showResult('Network error: ' + error.message, true);
+        }
+    });
+}
+
+// Bates numbering page
+function initBatesPage() {
+    const form = document.getElementById('batesForm');
+    const fileInput = document.getElementById('fileInput');
+    const fileList = document.getElementById('fileList');
+    const fileItems = document.getElementById('fileItems');
+    let selectedFiles = [];
+
+    fileInput.addEventListener('change', function(e) {
+        selectedFiles = Array.from(e.target.files);
+        updateFileList();
+    });
+
+    function updateFileList() {
+        if (selectedFiles.length === 0) {
+            fileList.style.display = 'none';
+            document.getElementById('submitBtn').disabled = true;
+            return;
+        }
+
+        fileList.style.display = 'block';
+        document.getElementById('submitBtn').disabled = false;
+
+        fileItems.innerHTML = selectedFiles.map((file, index) => `
+            <div class="file-item" draggable="true" data-index="${index}">
+                <span class="file-item-name">${index + 1}. ${file.name}</span>
+                <span class="file-item-remove" onclick="removeFile(${index})">✕</span>
+            </div>
+        `).join('');
+
+        enableDragReorder(fileItems, selectedFiles, updateFileList);
+    }
+
+    window.removeFile = function(index) {
+        selectedFiles.splice(index, 1);
+        updateFileList();
+    };
+
+    form.addEventListener('submit', async function(e) {
+        e.preventDefault();
+
+        if (selectedFiles.length === 0) {
+            showResult('Please select at least one PDF file', true);
+            return;
+        }
+
+        showProgress();
+
+        const formData = new FormData(form);
+        formData.delete('files');
+        selectedFiles.forEach(file => {
+            formData.append('files', file);
+        });
+
+        try {
+            const response = await fetch('/api/bates', {
+                method: 'POST',
+                body: formData
+            });
+
+            const data = await response.json();
+
+            if (response.ok) {
+                showResult(data.message, false, data.downloadUrl);
+            } else {
+                showResult(data.error || 'Failed to add Bates numbers', true);
+            }
+        } catch (error) {
+            showResult('Network error: ' + error.message, true);
+        }
+    });
+}
+
+// Insert pages page
+function initInsertPage() {
+    const form = document.getElementById('insertForm');
+    const blankOptions = document.getElementById('blankOptions');
+    const pdfOptions = document.getElementById('pdfOptions');
+    const insertFile = document.getElementById('insertFile');
+    const pagesRadio = document.getElementById('targetPages');
+    const pageRangeInput = document.getElementById('pageRange');
+    const everyInput = document.getElementById('every');
+
+    // Toggle blank page and PDF options
+    document.querySelectorAll('input[name="insertType"]').forEach(radio => {
+        radio.addEventListener('change', function() {
+            const isPdf = this.value === 'pdf';
+            blankOptions.style.display = isPdf ? 'none' : 'block';
+            pdfOptions.style.display = isPdf ? 'block' : 'none';
+        });
+    });
+
+    // Enable only the input that belongs to the selected target
+    document.querySelectorAll('input[name="target"]').forEach(radio => {
+        radio.addEventListener('change', function() {
+            pageRangeInput.disabled = !pagesRadio.checked;
+            everyInput.disabled = pagesRadio.checked;
+        });
+    });
+
+    form.addEventListener('submit', async function(e) {
+        e.preventDefault();
+
+        const formData = new FormData(form);
+
+        if (formData.get('insertType') === 'pdf' && insertFile.files.length === 0) {
+            showResult('Please choose a PDF to insert', true);
+            return;
+        }
+
+        if (formData.get('target') === 'pages' && !formData.get('pageRange').trim()) {
+            showResult('Please enter the pages to insert next to', true);
+            return;
+        }
+
+        showProgress();
+
+        try {
+            const response = await fetch('/api/insert', {
+                method: 'POST',
+                body: formData
+            });
+
+            const data = await response.json();
+
+            if (response.ok) {
+                showResult(data.message, false, data.downloadUrl);
+            } else {
+                showResult(data.error || 'Failed to insert pages', true);
+            }
+        } catch (error) {
+            showResult('Network error: ' + error.message, true);
+        }
+    });
+}
+
+// Metadata page
+function initMetadataPage() {
+    const form = document.getElementById('metadataForm');
+    const fileInput = document.getElementById('fileInput');
+    const fields = document.getElementById('metadataFields');
+    const extra = document.getElementById('metadataExtra');
+    const submitBtn = document.getElementById('submitBtn');
+    const textFields = ['title', 'author', 'subject', 'keywords', 'creator', 'producer'];
+    const dateFields = ['creationDate', 'modDate'];
+
+    // datetime-local inputs show local time without a zone
+    function toLocalInput(iso) {
+        if (!iso) {
+            return '';
+        }
+        const date = new Date(iso);
+        const pad = n => String(n).padStart(2, '0');
+        return `${date.getFullYear()}-${pad(date.getMonth() + 1)}-${pad(date.getDate())}` +
+            `T${pad(date.getHours())}:${pad(date.getMinutes())}:${pad(date.getSeconds())}`;
+    }
+
+    function showMetadata(metadata) {
+        textFields.forEach(name => {
+            document.getElementById(name).value = metadata[name] || '';
+        });
+        dateFields.forEach(name => {
+            document.getElementById(name).value = toLocalInput(metadata[name]);
+        });
+
+        // Custom entries are kept when saving; list them so they are not a surprise
+        extra.innerHTML = '';
+        const notes = [];
+        Object.keys(metadata.custom || {}).sort().forEach(key => {
+            notes.push(`${key}: ${metadata.custom[key]}`);
+        });
+        if (metadata.hasXMP) {
+            notes.push('This document also has an XMP metadata stream.');
+        }
+        notes.forEach(note => {
+            const p = document.createElement('p');
+            p.className = 'option-hint';
+            p.textContent = note;
+            extra.appendChild(p);
+        });
+        extra.style.display = notes.length > 0 ? 'block' : 'none';
+    }
+
+    // Load the current metadata into the form when a file is chosen
+    fileInput.addEventListener('change', async function(e) {
+        const file = e.target.files[0];
+        if (!file) {
+            return;
+        }
+
+        const formData = new FormData();
+        formData.append('file', file);
+        formData.append('action', 'inspect');
+
+        try {
+            const response = await fetch('/api/metadata', {
+                method: 'POST',
+                body: formData
+            });
+
+            const data = await response.json();
+
+            if (response.ok) {
+                showMetadata(data.metadata || {});
+            } else {
+                showResult(data.error || 'Failed to read PDF', true);
+            }
+        } catch (error) {
+            showResult('Network error: ' + error.message, true);
+        }
+    });
+
+    // Hide the fields when stripping everything
+    document.querySelectorAll('input[name="action"]').forEach(radio => {
+        radio.addEventListener('change', function() {
+            const strip = this.value === 'strip';
+            fields.style.display = strip ? 'none' : 'block';
+            submitBtn.textContent = strip ? 'Strip Metadata' : 'Save Metadata';
+        });
+    });
+
+    form.addEventListener('submit', async function(e) {
+        e.preventDefault();
+
+        const formData = new FormData(form);
+        dateFields.forEach(name => {
+            const value = document.getElementById(name).value;
+            formData.append(name, value ? new Date(value).toISOString() : '');
+        });
+
+        showProgress();
+
+        try {
+            const response = await fetch('/api/metadata', {
+                method: 'POST',
+                body: formData
+            });
+
+            const data = await response.json();
+
+            if (response.ok) {
+                showResult(data.message, false, data.downloadUrl);
+            } else {
+                showResult(data.error || 'Failed to process metadata', true);
+            }
+        } catch (error) {
+            showResult('Network error: ' + error.message, true);
+        }
+    });
+}
+
+// Change password page
+function initChangePasswordPage() {
+    const form = document.getElementById('changePasswordForm');
+    const currentPasswordInput = document.getElementById('currentPasswordInput');
+
+    initEncryptionOptions();
+
+    form.addEventListener('submit', async function(e) {
+        e.preventDefault();
+
+        if (!currentPasswordInput.value) {
+            showResult('Please enter the current owner password', true);
+            return;
+        }
+
+        showProgress();
+
+        const formData = new FormData(form);
+
+        try {
+            const response = await fetch('/api/change-password', {
+                method: 'POST',
+                body: formData
+            });
+
+            const data = await response.json();
+
+            if (response.ok) {
+                showResult(data.message, false, data.downloadUrl);
+            } else {
+                showResult(data.error || 'Failed to change password', true);
+            }
+        } catch (error) {
+            showResult('Network error: ' + error.message, true);
+        }
+    });
+}
+
+// Extract images page
+function initExtractImagesPage() {
+    const form = document.getElementById('extractImagesForm');
+    const compressCheckbox = document.getElementById('compress');
+    const compressOptions = document.getElementById('compressOptions');
+    const qualitySlider = document.getElementById('quality');
+    const qualityValue = document.getElementById('qualityValue');
+
+    compressCheckbox.addEventListener('change', function() {
+        compressOptions.style.display = this.checked ? 'block' : 'none';
+    });
+
+    qualitySlider.addEventListener('input', function() {
+        qualityValue.textContent = this.value;
+    });
+
+    form.addEventListener('submit', async function(e) {
+        e.preventDefault();
+
+        showProgress();
+
+        const formData = new FormData(form);
+
+        try {
+            const response = await fetch('/api/extract-images', {
+                method: 'POST',
+                body: formData
+            });
+
+            const data = await response.json();
+
+            if (response.ok) {
+                showResult(data.message, false, data.downloadUrl);
+            } else {
+                showResult(data.error || 'Failed to extract images', true);
+            }
+        } catch (error) {
+            showResult('Network error: ' + error.message, true);
+        }
+    });
+}
+
+// Extract text page
+function initExtractTextPage() {
+    const form = document.getElementById('extractTextForm');
+    const runsOption = document.getElementById('runsOption');
+
+    // Positioned runs are only part of the JSON output
+    document.querySelectorAll('input[name="format"]').forEach(radio => {
+        radio.addEventListener('change', function() {
+            runsOption.style.display = this.value === 'json' ? 'block' : 'none';
+        });
+    });
+
+    form.addEventListener('submit', async function(e) {
+        e.preventDefault();
+
+        showProgress();
+
+        const formData = new FormData(form);
+
+        try {
+            const response = await fetch('/api/extract-text', {
+                method: 'POST',
+                body: formData
+            });
+
+            const data = await response.json();
+
+            if (response.ok) {
+                showResult(data.message, false, data.downloadUrl);
+            } else {
+                showResult(data.error || 'Failed to extract text', true);
+            }
+        } catch (error) {
+            showResult('Network error: ' + error.message, true);
+        }
+    });
+}
+
+// Attachments page
+function initAttachmentsPage() {
+    const form = document.getElementById('attachmentsForm');
+    const fileInput = document.getElementById('fileInput');
+    const attachmentList = document.getElementById('attachmentList');
+    const attachmentItems = document.getElementById('attachmentItems');
+    const removeAllOption = document.getElementById('removeAllOption');
+    const addOptions = document.getElementById('addOptions');
+    const submitBtn = document.getElementById('submitBtn');
+    const buttonLabels = {
+        extract: 'Extract Attachments',
+        remove: 'Remove Attachments',
+        add: 'Attach Files'
+    };
+
+    function formatSize(bytes) {
+        if (bytes < 1024) {
+            return `${bytes} B`;
+        }
+        if (bytes < 1024 * 1024) {
+            return `${(bytes / 1024).toFixed(1)} KB`;
+        }
+        return `${(bytes / (1024 * 1024)).toFixed(2)} MB`;
+    }
+
+    function showAttachments(attachments) {
+        attachmentItems.innerHTML = '';
+        if (attachments.length === 0) {
+            const p = document.createElement('p');
+            p.className = 'option-hint';
+            p.textContent = 'This PDF has no attachments.';
+            attachmentItems.appendChild(p);
+        }
+        attachments.forEach((attachment, index) => {
+            const item = document.createElement('div');
+            item.className = 'file-item';
+
+            const checkbox = document.createElement('input');
+            checkbox.type = 'checkbox';
+            checkbox.id = `attachment${index}`;
+            checkbox.name = 'names';
+            checkbox.value = attachment.name;
+
+            const label = document.createElement('label');
+            label.htmlFor = checkbox.id;
+            label.className = 'file-item-name';
+            label.textContent = `${attachment.name} (${formatSize(attachment.size)})` +
+                (attachment.description ? ` - ${attachment.description}` : '');
+
+            item.appendChild(checkbox);
+            item.appendChild(label);
+            attachmentItems.appendChild(item);
+        });
+        attachmentList.style.display = 'block';
+    }
+
+    // List the attachments when a file is chosen
+    fileInput.addEventListener('change', async function(e) {
+        const file = e.target.files[0];
+        if (!file) {
+            return;
+        }
+
+        const formData = new FormData();
+        formData.append('file', file);
+        formData.append('action', 'list');
+
+        try {
+            const response = await fetch('/api/attachments', {
+                method: 'POST',
+                body: formData
+            });
+
+            const data = await response.json();
+
+            if (response.ok) {
+                showAttachments(data.attachments || []);
+            } else {
+                showResult(data.error || 'Failed to read PDF', true);
+            }
+        } catch (error) {
+            showResult('Network error: ' + error.message, true);
+        }
+    });
+
+    document.querySelectorAll('input[name="action"]').forEach(radio => {
+        radio.addEventListener('change', function() {
+            removeAllOption.style.display = this.value === 'remove' ? 'block' : 'none';
+            addOptions.style.display = this.value === 'add' ? 'block' : 'none';
+            submitBtn.textContent = buttonLabels[this.value];
+        });
+    });
+
+    form.addEventListener('submit', async function(e) {
+        e.preventDefault();
+
+        const formData = new FormData(form);
+        const action = formData.get('action');
+
+        if (action === 'add') {
+            // Selected attachments only matter for extract and remove
+            formData.delete('names');
+            const count = document.getElementById('attachmentsInput').files.length;
+            if (count === 0) {
+                showResult('Please choose files to attach', true);
+                return;
+            }
+            const description = document.getElementById('description').value;
+            for (let i = 0; i < count; i++) {
+                formData.append('descriptions', description);
+            }
+        } else if (action === 'remove' && formData.getAll('names').length === 0 && !formData.get('all')) {
+            showResult('Please select attachments to remove, or remove all of them', true);
+            return;
+        }
+
+        showProgress();
+
+        try {
+            const response = await fetch('/api/attachments', {
+                method: 'POST',
+                body: formData
+            });
+
+            const data = await response.json();
+
+            if (response.ok) {
+                showResult(data.message, false, data.downloadUrl);
+            } else {
+                showResult(data.error || 'Failed to process attachments', true);
+            }
+        } catch (error) {
+            showResult('Network error: ' + error.message, true);
+        }
+    });
+}
+
+// Form filling page
+function initFormPage() {
+    const form = document.getElementById('formFillForm');
+    const fileInput = document.getElementById('fileInput');
+    const formFields = document.getElementById('formFields');
+    const formFieldItems = document.getElementById('formFieldItems');
+    const batchOptions = document.getElementById('batchOptions');
+    const csvTemplate = document.getElementById('csvTemplate');
+    const csvTemplateLink = document.getElementById('csvTemplateLink');
+    let fields = [];
+
+    function fieldInput(field, index) {
+        const id = `field${index}`;
+        const options = field.options || [];
+
+        if (field.type === 'checkbox') {
+            const input = document.createElement('input');
+            input.type = 'checkbox';
+            input.id = id;
+            input.checked = field.value === 'true';
+            return input;
+        }
+
+        if (field.type === 'radio' || field.type === 'combobox' || field.type === 'listbox') {
+            const select = document.createElement('select');
+            select.id = id;
+            select.multiple = field.type === 'listbox' && field.multiple;
+            const selected = field.type === 'listbox' ? field.value.split(',') : [field.value];
+            if (!select.multiple) {
+                select.appendChild(new Option('', ''));
+            }
+            options.forEach(option => {
+                select.appendChild(new Option(option, option, false, selected.includes(option)));
+            });
+            return select;
+        }
+
+        const input = document.createElement(field.multiline ? 'textarea' : 'input');
+        input.id = id;
+        input.value = field.value;
+        if (field.type === 'date') {
+            input.placeholder = field.format;
+        }
+        if (field.maxLen) {
+            input.maxLength = field.maxLen;
+        }
+        return input;
+    }
+
+    function showFields() {
+        formFieldItems.innerHTML = '';
+        fields.forEach((field, index) => {
+            const item = document.createElement('div');
+            item.className = 'option';
+
+            const label = document.createElement('label');
+            label.htmlFor = `field${index}`;
+            label.textContent = (field.label || field.name) + ':';
+
+            const input = fieldInput(field, index);
+            input.disabled = field.locked;
+
+            item.appendChild(label);
+            item.appendChild(input);
+            formFieldItems.appendChild(item);
+        });
+        formFields.style.display = document.getElementById('modeSingle').checked ? 'block' : 'none';
+        csvTemplate.style.display = 'block';
+    }
+
+    function fieldValues() {
+        const values = {};
+        fields.forEach((field, index) => {
+            const input = document.getElementById(`field${index}`);
+            if (field.locked) {
+                return;
+            }
+            if (field.type === 'checkbox') {
+                values[field.name] = input.checked;
+            } else if (field.type === 'listbox') {
+                const selected = Array.from(input.selectedOptions).map(option => option.value).filter(v => v);
+                if (selected.length > 0) {
+                    values[field.name] = selected;
+                }
+            } else if (field.type === 'radio') {
+                if (input.value) {
+                    values[field.name] = input.value;
+                }
+            } else {
+                values[field.name] = input.value;
+            }
+        });
+        return values;
+    }
+
+    // Load the fields when a file is chosen
+    fileInput.addEventListener('change', async function(e) {
+        const file = e.target.files[0];
+        if (!file) {
+            return;
+        }
+
+        const formData = new FormData();
+        formData.append('file', file);
+
+        try {
+            const response = await fetch('/api/form/fields', {
+                method: 'POST',
+                body: formData
+            });
+
+            const data = await response.json();
+
+            if (response.ok) {
+                fields = data.fields || [];
+                showFields();
+            } else {
+                fields = [];
+                formFieldItems.innerHTML = '';
+                csvTemplate.style.display = 'none';
+                showResult(data.error || 'Failed to read form', true);
+            }
+        } catch (error) {
+            showResult('Network error: ' + error.message, true);
+        }
+    });
+
+    document.querySelectorAll('input[name="mode"]').forEach(radio => {
+        radio.addEventListener('change', function() {
+            formFields.style.display = this.value === 'single' && fields.length > 0 ? 'block' : 'none';
+            batchOptions.style.display = this.value === 'batch' ? 'block' : 'none';
+        });
+    });
+
+    csvTemplateLink.addEventListener('click', function(e) {
+        e.preventDefault();
+        const quote = s => /[",\n]/.test(s) ? `"${s.replace(/"/g, '""')}"` : s;
+        const names = fields.filter(field => !field.locked).map(field => quote(field.name));
+        const blob = new Blob([names.join(',') + '\n'], { type: 'text/csv' });
+        const link = document.createElement('a');
+        link.href = URL.createObjectURL(blob);
+        link.download = 'form.csv';
+        link.click();
+        URL.revokeObjectURL(link.href);
+    });
+
+    form.addEventListener('submit', async function(e) {
+        e.preventDefault();
+
+        const batch = document.getElementById('modeBatch').checked;
+        const dataInput = document.getElementById('data');
+
+        const formData = new FormData();
+        formData.append('file', fileInput.files[0]);
+        if (batch) {
+            if (dataInput.files.length === 0) {
+                showResult('Please choose a CSV file', true);
+                return;
+            }
+            formData.append('data', dataInput.files[0]);
+        } else {
+            if (fields.length === 0) {
+                showResult('This PDF has no fields to fill', true);
+                return;
+            }
+            formData.append('values', JSON.stringify(fieldValues()));
+        }
+
+        showProgress();
+
+        try {
+            const response = await fetch('/api/form/fill', {
+                method: 'POST',
+                body: formData
+            });
+
+            const data = await response.json();
+
+            if (response.ok) {
+                showResult(data.message, false, data.downloadUrl);
+            } else {
+                showResult(data.error || 'Failed to fill form', true);
+            }
+        } catch (error) {
+            showResult('Network error: ' + error.message, true);
+        }
+    });
+}
+
+// Flatten page
+function initFlattenPage() {
+    const form = document.getElementById('flattenForm');
+
+    form.addEventListener('submit', async function(e) {
+        e.preventDefault();
+
+        showProgress();
+
+        const formData = new FormData(form);
+
+        try {
+            const response = await fetch('/api/flatten', {
+                method: 'POST',
+                body: formData
+            });
+
+            const data = await response.json();
+
+            if (response.ok) {
+                showResult(data.message, false, data.downloadUrl);
+            } else {
+                showResult(data.error || 'Failed to flatten PDF', true);
+            }
+        } catch (error) {
+            showResult('Network error: ' + error.message, true);
+        }
+    });
+}
+
+// Annotations page
+function initAnnotationsPage() {
+    const form = document.getElementById('annotationsForm');
+    const fileInput = document.getElementById('fileInput');
+    const annotationList = document.getElementById('annotationList');
+    const annotationItems = document.getElementById('annotationItems');
+
+    // Checkboxes for the distinct values of a field, e.g. every author in the document
+    function showChoices(containerId, itemsId, name, values) {
+        const items = document.getElementById(itemsId);
+        items.innerHTML = '';
+        values.forEach((value, index) => {
+            const item = document.createElement('div');
+
+            const checkbox = document.createElement('input');
+            checkbox.type = 'checkbox';
+            checkbox.id = `${name}${index}`;
+            checkbox.name = name;
+            checkbox.value = value;
+
+            const label = document.createElement('label');
+            label.htmlFor = checkbox.id;
+            label.textContent = value;
+
+            item.appendChild(checkbox);
+            item.appendChild(label);
+            items.appendChild(item);
+        });
+        document.getElementById(containerId).style.display = values.length > 0 ? 'block' : 'none';
+    }
+
+    function showAnnotations(annotations) {
+        annotationItems.innerHTML = '';
+        if (annotations.length === 0) {
+            const p = document.createElement('p');
+            p.className = 'option-hint';
+            p.textContent = 'This PDF has no annotations.';
+            annotationItems.appendChild(p);
+        }
+        annotations.forEach(annotation => {
+            const item = document.createElement('div');
+            item.className = 'file-item';
+
+            const name = document.createElement('span');
+            name.className = 'file-item-name';
+            let text = `Page ${annotation.page}: ${annotation.type}`;
+            if (annotation.author) {
+                text += ` by ${annotation.author}`;
+            }
+            if (annotation.modified) {
+                text += ` (${new Date(annotation.modified).toLocaleString()})`;
+            }
+            if (annotation.contents) {
+                text += ` - ${annotation.contents}`;
+            }
+            name.textContent = text;
+
+            item.appendChild(name);
+            annotationItems.appendChild(item);
+        });
+        annotationList.style.display = 'block';
+
+        // Form fields cannot be removed here, so they are not offered
+        const types = [...new Set(annotations.map(a => a.type).filter(t => t !== 'Widget'))].sort();
+        const authors = [...new Set(annotations.map(a => a.author).filter(a => a))].sort();
+        showChoices('typeOptions', 'typeItems', 'types', types);
+        showChoices('authorOptions', 'authorItems', 'authors', authors);
+    }
+
+    // List the annotations when a file is chosen
+    fileInput.addEventListener('change', async function(e) {
+        const file = e.target.files[0];
+        if (!file) {
+            return;
+        }
+
+        const formData = new FormData();
+        formData.append('file', file);
+        formData.append('action', 'list');
+
+        try {
+            const response = await fetch('/api/annotations', {
+                method: 'POST',
+                body: formData
+            });
+
+            const data = await response.json();
+
+            if (response.ok) {
+                showAnnotations(data.annotations || []);
+            } else {
+                showResult(data.error || 'Failed to read PDF', true);
+            }
+        } catch (error) {
+            showResult('Network error: ' + error.message, true);
+        }
+    });
+
+    form.addEventListener('submit', async function(e) {
+        e.preventDefault();
+
+        const formData = new FormData(form);
+        formData.append('action', 'remove');
+
+        if (!formData.get('pageRange').trim() && formData.getAll('types').length === 0 &&
+            formData.getAll('authors').length === 0 && !formData.get('all')) {
+            showResult('Please choose the annotations to remove, or remove all of them', true);
+            return;
+        }
+
+        showProgress();
+
+        try {
+            const response = await fetch('/api/annotations', {
+                method: 'POST',
+                body: formData
+            });
+
+            const data = await response.json();
+
+            if (response.ok) {
+                showResult(data.message, false, data.downloadUrl);
+            } else {
+                showResult(data.error || 'Failed to remove annotations', true);
+            }
+        } catch (error) {
+            showResult('Network error: ' + error.message, true);
+        }
+    });
+}
+
+// Bookmarks page
+function initBookmarksPage() {
+    const form = document.getElementById('bookmarksForm');
+    const fileInput = document.getElementById('fileInput');
+    const bookmarkList = document.getElementById('bookmarkList');
+    const bookmarkItems = document.getElementById('bookmarkItems');
+    const downloadLink = document.getElementById('bookmarksDownloadLink');
+    const bookmarksText = document.getElementById('bookmarks');
+    const bookmarksFile = document.getElementById('bookmarksFile');
+    let exported = [];
+
+    function addBookmarkItems(bookmarks, depth) {
+        bookmarks.forEach(bookmark => {
+            const item = document.createElement('div');
+            item.className = 'file-item';
+            item.style.paddingLeft = `${depth * 20}px`;
+
+            const name = document.createElement('span');
+            name.className = 'file-item-name';
+            name.textContent = `${bookmark.title} (page ${bookmark.page})`;
+
+            item.appendChild(name);
+            bookmarkItems.appendChild(item);
+            addBookmarkItems(bookmark.children || [], depth + 1);
+        });
+    }
+
+    function showBookmarks(bookmarks) {
+        exported = bookmarks;
+        bookmarkItems.innerHTML = '';
+        if (bookmarks.length === 0) {
+            const p = document.createElement('p');
+            p.className = 'option-hint';
+            p.textContent = 'This PDF has no bookmarks.';
+            bookmarkItems.appendChild(p);
+        }
+        addBookmarkItems(bookmarks, 0);
+        downloadLink.style.display = bookmarks.length > 0 ? 'inline' : 'none';
+        bookmarkList.style.display = 'block';
+
+        // Start from the current outline so it can be edited
+        if (bookmarks.length > 0 && !bookmarksText.value.trim()) {
+            bookmarksText.value = JSON.stringify(bookmarks, null, 2);
+        }
+    }
+
+    // Export the bookmarks when a file is chosen
+    fileInput.addEventListener('change', async function(e) {
+        const file = e.target.files[0];
+        if (!file) {
+            return;
+        }
+
+        const formData = new FormData();
+        formData.append('file', file);
+        formData.append('action', 'export');
+
+        try {
+            const response = await fetch('/api/bookmarks', {
+                method: 'POST',
+                body: formData
+            });
+
+            const data = await response.json();
+
+            if (response.ok) {
+                showBookmarks(data.bookmarks || []);
+            } else {
+                showResult(data.error || 'Failed to read PDF', true);
+            }
+        } catch (error) {
+            showResult('Network error: ' + error.message, true);
+        }
+    });
+
+    downloadLink.addEventListener('click', function(e) {
+        e.preventDefault();
+        const blob = new Blob([JSON.stringify(exported, null, 2) + '\n'], { type: 'application/json' });
+        const link = document.createElement('a');
+        link.href = URL.createObjectURL(blob);
+        link.download = 'bookmarks.json';
+        link.click();
+        URL.revokeObjectURL(link.href);
+    });
+
+    // An uploaded JSON file replaces the text so it can be checked before importing
+    bookmarksFile.addEventListener('change', async function(e) {
+        const file = e.target.files[0];
+        if (file) {
+            bookmarksText.value = await file.text();
+        }
+    });
+
+    form.addEventListener('submit', async function(e) {
+        e.preventDefault();
+
+        if (!bookmarksText.value.trim()) {
+            showResult('Please enter the bookmarks to import', true);
+            return;
+        }
+
+        showProgress();
+
+        const formData = new FormData(form);
+        formData.append('action', 'import');
+
+        try {
+            const response = await fetch('/api/bookmarks', {
+                method: 'POST',
+                body: formData
+            });
+
+            const data = await response.json();
+
+            if (response.ok) {
+                showResult(data.message, false, data.downloadUrl);
+            } else {
+                showResult(data.error || 'Failed to import bookmarks', true);
             }
         } catch (error) {
             showResult('Network error: ' + error.message, true);